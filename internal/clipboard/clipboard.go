@@ -0,0 +1,139 @@
+// Package clipboard copies text to the system clipboard with xclip, xsel,
+// wl-copy, pbcopy, or the OSC 52 terminal escape sequence.
+package clipboard
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// ErrNotFound is returned if no clipboard is available.
+var ErrNotFound = errors.New("clipboard not found. please install xclip, xsel, or wl-clipboard, or use a terminal supporting OSC 52")
+
+// ErrUnreadable is returned by clipboards that can only be written, e.g. OSC 52.
+var ErrUnreadable = errors.New("clipboard can not be read")
+
+// Clipboard reads and writes the system clipboard.
+type Clipboard interface {
+	Name() string
+	Read() (string, error)
+	Write(s string) error
+}
+
+// command runs the programs to copy and paste.
+type command struct {
+	copy  []string
+	paste []string
+}
+
+func (c *command) Name() string {
+	return c.copy[0]
+}
+
+func (c *command) Read() (string, error) {
+	b, err := exec.Command(c.paste[0], c.paste[1:]...).Output()
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", c.paste[0], err)
+	}
+	return string(b), nil
+}
+
+func (c *command) Write(s string) error {
+	cmd := exec.Command(c.copy[0], c.copy[1:]...)
+	cmd.Stdin = strings.NewReader(s)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %v", c.copy[0], err)
+	}
+	return nil
+}
+
+// osc52 asks the terminal to set the clipboard, which also works over ssh.
+type osc52 struct {
+	w io.Writer
+}
+
+func (c *osc52) Name() string {
+	return "terminal"
+}
+
+func (c *osc52) Read() (string, error) {
+	return "", ErrUnreadable
+}
+
+func (c *osc52) Write(s string) error {
+	_, err := fmt.Fprintf(c.w, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(s)))
+	return err
+}
+
+var commands = []struct {
+	env   string
+	goos  string
+	copy  []string
+	paste []string
+}{
+	{env: "WAYLAND_DISPLAY", copy: []string{"wl-copy"}, paste: []string{"wl-paste", "--no-newline"}},
+	{env: "DISPLAY", copy: []string{"xclip", "-selection", "clipboard", "-in"}, paste: []string{"xclip", "-selection", "clipboard", "-out"}},
+	{env: "DISPLAY", copy: []string{"xsel", "--clipboard", "--input"}, paste: []string{"xsel", "--clipboard", "--output"}},
+	{goos: "darwin", copy: []string{"pbcopy"}, paste: []string{"pbpaste"}},
+}
+
+// Detect returns the first available clipboard.
+func Detect() (Clipboard, error) {
+	for _, c := range commands {
+		if c.env != "" && os.Getenv(c.env) == "" {
+			continue
+		}
+		if c.goos != "" && c.goos != runtime.GOOS {
+			continue
+		}
+		if _, err := exec.LookPath(c.copy[0]); err != nil {
+			continue
+		}
+		if _, err := exec.LookPath(c.paste[0]); err != nil {
+			continue
+		}
+		return &command{copy: c.copy, paste: c.paste}, nil
+	}
+	if term.IsTerminal(int(os.Stderr.Fd())) {
+		return &osc52{w: os.Stderr}, nil
+	}
+	return nil, ErrNotFound
+}
+
+// Clear empties the clipboard if it still holds the value, or if it can not be
+// read. It reports whether the clipboard was cleared.
+func Clear(c Clipboard, value string) (bool, error) {
+	s, err := c.Read()
+	switch {
+	case err == ErrUnreadable:
+	case err != nil:
+		return false, err
+	case s != value:
+		return false, nil
+	}
+	if err := c.Write(""); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ClearAfter waits for the duration or until done is closed, then clears the
+// clipboard if it still holds the value.
+func ClearAfter(c Clipboard, value string, d time.Duration, done <-chan struct{}) (bool, error) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-done:
+	}
+	return Clear(c, value)
+}
@@ -0,0 +1,101 @@
+package clipboard
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeClipboard struct {
+	sync.Mutex
+	s          string
+	unreadable bool
+}
+
+func (c *fakeClipboard) Name() string {
+	return "fake"
+}
+
+func (c *fakeClipboard) Read() (string, error) {
+	c.Lock()
+	defer c.Unlock()
+	if c.unreadable {
+		return "", ErrUnreadable
+	}
+	return c.s, nil
+}
+
+func (c *fakeClipboard) Write(s string) error {
+	c.Lock()
+	defer c.Unlock()
+	c.s = s
+	return nil
+}
+
+func TestClear(t *testing.T) {
+	tests := []struct {
+		content    string
+		unreadable bool
+		cleared    bool
+		want       string
+	}{
+		{"secret", false, true, ""},
+		{"other", false, false, "other"},
+		{"", false, false, ""},
+		{"other", true, true, ""},
+	}
+
+	for i, test := range tests {
+		c := &fakeClipboard{s: test.content, unreadable: test.unreadable}
+		cleared, err := Clear(c, "secret")
+		if err != nil {
+			t.Fatalf("[%v] err: %v", i, err)
+		}
+		if cleared != test.cleared || c.s != test.want {
+			t.Fatalf("[%v] got: %v %q want: %v %q", i, cleared, c.s, test.cleared, test.want)
+		}
+	}
+}
+
+func TestClearAfter(t *testing.T) {
+	c := &fakeClipboard{}
+	c.Write("secret")
+
+	start := time.Now()
+	cleared, err := ClearAfter(c, "secret", 10*time.Millisecond, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := c.Read(); !cleared || got != "" {
+		t.Fatalf("got: %v %q want: %v %q", cleared, got, true, "")
+	}
+	if d := time.Since(start); d < 10*time.Millisecond {
+		t.Fatalf("cleared too early: %v", d)
+	}
+
+	// copied by the user in the meantime
+	c.Write("secret")
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		c.Write("other")
+	}()
+	cleared, err = ClearAfter(c, "secret", 20*time.Millisecond, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := c.Read(); cleared || got != "other" {
+		t.Fatalf("got: %v %q want: %v %q", cleared, got, false, "other")
+	}
+
+	// interrupted
+	c.Write("secret")
+	done := make(chan struct{})
+	close(done)
+	cleared, err = ClearAfter(c, "secret", time.Hour, done)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := c.Read(); !cleared || got != "" {
+		t.Fatalf("got: %v %q want: %v %q", cleared, got, true, "")
+	}
+}
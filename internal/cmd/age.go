@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal/log"
+	"github.com/gostones/spa/internal/sec"
+)
+
+const (
+	defaultAgeLabel = "default"
+
+	// stdio reads from the standard input or writes to the standard output.
+	stdio = "-"
+)
+
+const (
+	ageIdentityWarning = `
+Warning: anyone with the identity below can decrypt your files.
+
+`
+	ageOverwrite = `
+File %q exists. Its content will be overwritten.
+
+`
+	agePrompt = `Continue? [y/N] `
+)
+
+// ageIdentity derives the identity of the label and its recipient.
+func ageIdentity(label string) ([]byte, []byte, error) {
+	id, err := sec.AgeIdentity(cfg.Secret.Stock, label)
+	if err != nil {
+		return nil, nil, err
+	}
+	pub, err := sec.AgeRecipient(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return id, pub, nil
+}
+
+func showAgeIdentity() error {
+	id, pub, err := ageIdentity(cfg.Age.Label)
+	if err != nil {
+		return err
+	}
+	recipient, err := sec.EncodeAgeRecipient(pub)
+	if err != nil {
+		return err
+	}
+
+	if !cfg.Age.Identity {
+		log.Infoln(recipient)
+		return nil
+	}
+
+	s, err := sec.EncodeAgeIdentity(id)
+	if err != nil {
+		return err
+	}
+	log.Infof(ageIdentityWarning)
+	log.Infof("# recipient: %s\n", recipient)
+	log.Infoln(s)
+	return nil
+}
+
+func openInput(p string) (io.ReadCloser, error) {
+	if p == stdio {
+		return os.Stdin, nil
+	}
+	return os.Open(p)
+}
+
+// createOutput creates the file, the returned function removes the file if
+// the output is incomplete.
+func createOutput(p string) (io.WriteCloser, func(), error) {
+	if p == stdio {
+		return os.Stdout, func() {}, nil
+	}
+	if checkFile(p) {
+		log.Infof(ageOverwrite, p)
+		choice, err := log.Confirm(agePrompt)
+		if err != nil {
+			return nil, nil, err
+		}
+		if choice == "n" {
+			return nil, nil, fmt.Errorf("")
+		}
+	}
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, func() { os.Remove(p) }, nil
+}
+
+// ageCmd represents the age command
+var ageCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Use:                   "age [--label <LABEL>] [--identity]",
+	Short:                 "Show age recipient",
+	Long: `
+Show the age recipient derived from your secret for the label.
+
+Files encrypted to the recipient with 'spa encrypt' or any age compatible tool
+can be decrypted with 'spa decrypt' using the same secret, salt, and label; no
+private key is stored on disk.
+
+The identity (private key) is shown with --identity for use with other tools.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := requireSecret(); err != nil {
+			exit(err)
+		}
+
+		err := showAgeIdentity()
+		exit(err)
+	},
+}
+
+func addAgeFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&cfg.Salt.Raw, "salt", "", "specify a salt to use, whitespaces are ignored. default: saved in ~/.spa/salt")
+	cmd.Flags().StringVarP(&cfg.Secret.Raw, "secret", "s", "", "your secret")
+	cmd.Flags().StringVar(&cfg.Age.Label, "label", defaultAgeLabel, "optional label to derive a different identity.")
+
+	cmd.Flags().MarkHidden("salt")
+	cmd.Flags().MarkHidden("secret")
+}
+
+func init() {
+	rootCmd.AddCommand(ageCmd)
+
+	addAgeFlags(ageCmd)
+	ageCmd.Flags().BoolVar(&cfg.Age.Identity, "identity", false, "show the identity, i.e. the private key.")
+}
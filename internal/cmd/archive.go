@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/gostones/spa/internal/sec"
+)
+
+// Backup archive file format
+//
+// a single line json header followed by base64(encrypt(passphrase, json(archive))),
+// the header is authenticated as additional data
+const (
+	archiveMagic   = "spa-backup"
+	archiveVersion = 1
+)
+
+// names of the files in the archive
+const (
+	archiveKey    = "key"
+	archiveSalt   = "salt"
+	archivePepper = "pepper"
+)
+
+type archiveHeader struct {
+	Magic     string `json:"magic"`
+	Version   int    `json:"version"`
+	Cipher    string `json:"cipher"`
+	KDF       string `json:"kdf"`
+	Iteration int    `json:"iteration"`
+
+	// KDF profile, empty for the legacy profile
+	Profile string `json:"profile,omitempty"`
+}
+
+func newArchiveHeader() *archiveHeader {
+	return &archiveHeader{
+		Magic:     archiveMagic,
+		Version:   archiveVersion,
+		Cipher:    safeCipher,
+		KDF:       safeKDF,
+		Iteration: sec.CurrentKDFProfile().CryptIteration,
+		Profile:   kdfProfileTag(),
+	}
+}
+
+func (r *archiveHeader) validate() error {
+	if r.Magic != archiveMagic {
+		return fmt.Errorf("not a backup file: %q", r.Magic)
+	}
+	if r.Version > archiveVersion {
+		return fmt.Errorf("unsupported version: %v. please upgrade spa", r.Version)
+	}
+	if r.Cipher != safeCipher || r.KDF != safeKDF {
+		return fmt.Errorf("unsupported cipher: %q kdf: %q", r.Cipher, r.KDF)
+	}
+	if r.Iteration <= 0 || r.Iteration > maxSafeIteration {
+		return fmt.Errorf("invalid iteration: %v", r.Iteration)
+	}
+
+	// the header is read before it is authenticated, only the known profiles
+	// and their work factors are accepted.
+	name := r.Profile
+	if name == "" {
+		name = sec.LegacyKDFProfile
+	}
+	p, ok := sec.LookupKDFProfile(name)
+	if !ok {
+		return fmt.Errorf("unknown KDF profile: %q", r.Profile)
+	}
+	if r.Iteration != p.CryptIteration {
+		return fmt.Errorf("invalid iteration: %v for KDF profile %q", r.Iteration, name)
+	}
+	return nil
+}
+
+// manifestEntry records the size and checksum of a file in the archive.
+type manifestEntry struct {
+	Name   string `json:"name"`
+	Size   int    `json:"size"`
+	SHA512 string `json:"sha512"`
+}
+
+type archive struct {
+	Created  time.Time         `json:"created"`
+	Manifest []manifestEntry   `json:"manifest"`
+	Files    map[string][]byte `json:"files"`
+}
+
+func checksum(b []byte) string {
+	h := sha512.Sum512(b)
+	return hex.EncodeToString(h[:])
+}
+
+func newArchive(files map[string][]byte) *archive {
+	a := &archive{
+		Created: time.Now().UTC(),
+		Files:   files,
+	}
+	var names []string
+	for k := range files {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		a.Manifest = append(a.Manifest, manifestEntry{
+			Name:   k,
+			Size:   len(files[k]),
+			SHA512: checksum(files[k]),
+		})
+	}
+	return a
+}
+
+// verify checks the files against the manifest.
+func (r *archive) verify() error {
+	if len(r.Manifest) != len(r.Files) {
+		return fmt.Errorf("manifest mismatch: %v entries %v files", len(r.Manifest), len(r.Files))
+	}
+	for _, e := range r.Manifest {
+		b, ok := r.Files[e.Name]
+		if !ok {
+			return fmt.Errorf("file missing: %q", e.Name)
+		}
+		if len(b) != e.Size || checksum(b) != e.SHA512 {
+			return fmt.Errorf("checksum mismatch: %q", e.Name)
+		}
+	}
+	return nil
+}
+
+func sealArchive(passphrase []byte, h *archiveHeader, a *archive) ([]byte, error) {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	defer sec.Wipe(data)
+	hb, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	cipher, err := sec.EncryptAD(passphrase, data, hb, h.Iteration)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(hb)
+	buf.WriteByte('\n')
+	buf.WriteString(base64.StdEncoding.EncodeToString(cipher))
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func openArchive(passphrase []byte, b []byte) (*archive, error) {
+	b = bytes.TrimSpace(b)
+	idx := bytes.IndexByte(b, '\n')
+	if idx < 0 {
+		return nil, fmt.Errorf("not a backup file")
+	}
+	var h archiveHeader
+	if err := json.Unmarshal(b[:idx], &h); err != nil {
+		return nil, fmt.Errorf("invalid header: %v", err)
+	}
+	if err := h.validate(); err != nil {
+		return nil, err
+	}
+	if err := useKDFProfile(h.Profile); err != nil {
+		return nil, err
+	}
+
+	cipher, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(b[idx+1:])))
+	if err != nil {
+		return nil, err
+	}
+	// marshaled again as in sealArchive, only the known fields are bound.
+	ad, err := json.Marshal(&h)
+	if err != nil {
+		return nil, err
+	}
+	data, err := sec.DecryptAD(passphrase, cipher, ad, h.Iteration)
+	if err != nil {
+		return nil, fmt.Errorf("incorrect passphrase or corrupted file: %v", err)
+	}
+
+	var a archive
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, err
+	}
+	if err := a.verify(); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// archiveFilenames maps the names in the archive to the local files.
+func archiveFilenames() map[string]string {
+	return map[string]string{
+		archiveKey:    keyFilename(),
+		archiveSalt:   saltFilename(),
+		archivePepper: pepperFilename(),
+	}
+}
+
+// writeFile writes to a temporary file in the same directory and renames it,
+// so that an existing file is either kept or replaced as a whole.
+func writeFile(file string, b []byte) error {
+	dir := filepath.Dir(file)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	perm := os.FileMode(0600)
+	f, err := ioutil.TempFile(dir, "."+filepath.Base(file)+"-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gostones/spa/internal/sec"
+)
+
+func testArchiveFiles() map[string][]byte {
+	return map[string][]byte{
+		archiveKey:    []byte("key"),
+		archiveSalt:   []byte("0123456789abcdef"),
+		archivePepper: []byte("pepper"),
+	}
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	defer useKDFProfile(sec.LegacyKDFProfile)
+	if err := useKDFProfile("fast-ci"); err != nil {
+		t.Fatal(err)
+	}
+
+	passphrase := []byte("passphrase")
+	h := newArchiveHeader()
+
+	b, err := sealArchive(passphrase, h, newArchive(testArchiveFiles()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := openArchive(passphrase, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range testArchiveFiles() {
+		if !bytes.Equal(a.Files[k], v) {
+			t.Fatalf("[%s] got: %s want: %s", k, a.Files[k], v)
+		}
+	}
+
+	if _, err := openArchive([]byte("wrong passphrase"), b); err == nil {
+		t.Fatal("expected error with wrong passphrase")
+	}
+
+	// the header is authenticated
+	tampered := bytes.Replace(b, []byte(`"magic":"spa-backup"`), []byte(`"magic":"spa-backup","keyLen":1`), 1)
+	if _, err := openArchive(passphrase, tampered); err != nil {
+		t.Fatalf("unknown fields are ignored: %v", err)
+	}
+	tampered = bytes.Replace(b, []byte(`"version":1`), []byte(`"version":0`), 1)
+	if _, err := openArchive(passphrase, tampered); err == nil {
+		t.Fatal("expected error with tampered header")
+	}
+}
+
+func TestArchiveHeader(t *testing.T) {
+	tests := []struct {
+		iteration int
+		profile   string
+		ok        bool
+	}{
+		{6, "", true},
+		{1, "fast-ci", true},
+		{8, "paranoid", true},
+		{1, "", false},
+		{6, "fast-ci", false},
+		{0, "", false},
+		{65, "", false},
+		{2147483647, "", false},
+		{6, "other", false},
+	}
+	for i, tc := range tests {
+		h := newArchiveHeader()
+		h.Iteration = tc.iteration
+		h.Profile = tc.profile
+		if err := h.validate(); (err == nil) != tc.ok {
+			t.Fatalf("[%v] got: %v want ok: %v", i, err, tc.ok)
+		}
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := filepath.Join(dir, "sub", "salt")
+	for _, v := range []string{"old", "new"} {
+		if err := writeFile(p, []byte(v)); err != nil {
+			t.Fatal(err)
+		}
+		if b, _ := ioutil.ReadFile(p); string(b) != v {
+			t.Fatalf("got: %s want: %s", b, v)
+		}
+	}
+	files, _ := ioutil.ReadDir(filepath.Dir(p))
+	if len(files) != 1 {
+		t.Fatalf("got: %v files want: 1", len(files))
+	}
+}
+
+func TestArchiveVerify(t *testing.T) {
+	tests := []struct {
+		tamper func(*archive)
+		ok     bool
+	}{
+		{func(a *archive) {}, true},
+		{func(a *archive) { a.Files[archiveKey] = []byte("KEY") }, false},
+		{func(a *archive) { a.Files[archiveSalt] = []byte("0123") }, false},
+		{func(a *archive) { delete(a.Files, archivePepper) }, false},
+		{func(a *archive) { a.Manifest = a.Manifest[1:] }, false},
+		{func(a *archive) { a.Manifest[0].Name = "other" }, false},
+	}
+	for i, tc := range tests {
+		a := newArchive(testArchiveFiles())
+		tc.tamper(a)
+		if err := a.verify(); (err == nil) != tc.ok {
+			t.Fatalf("[%v] got: %v want ok: %v", i, err, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal/log"
+)
+
+const (
+	passphraseNewPrompt      = `Enter a passphrase for the backup: `
+	passphraseNewAgainPrompt = `Enter the passphrase again: `
+
+	backupOverwrite = `
+Backup file %q exists. Its content will be overwritten.
+
+`
+	backupPrompt = `Continue? [y/N] `
+	backupDone   = `Backup of %v files has been encrypted and saved in %q
+
+You should keep the backup and your passphrase in separate safe places.
+`
+)
+
+func backup() error {
+	files := make(map[string][]byte)
+	for name, file := range archiveFilenames() {
+		if !checkFile(file) {
+			if name == archivePepper {
+				continue
+			}
+			return fmt.Errorf("%s file not found: %q", name, file)
+		}
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		files[name] = b
+	}
+
+	p := cfg.Backup.File
+	if checkFile(p) {
+		log.Infof(backupOverwrite, p)
+		choice, err := log.Confirm(backupPrompt)
+		if err != nil {
+			return err
+		}
+		if choice == "n" {
+			return nil
+		}
+	}
+
+	if cfg.Backup.Passphrase == "" {
+		raw, err := enterNewPassphrase()
+		if err != nil {
+			return err
+		}
+		cfg.Backup.Passphrase = raw
+	}
+	if len(cfg.Backup.Passphrase) < minSecretLen {
+		return ErrPassphraseTooShort
+	}
+
+	a := newArchive(files)
+	b, err := sealArchive([]byte(cfg.Backup.Passphrase), newArchiveHeader(), a)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(p, b); err != nil {
+		return err
+	}
+
+	log.Infof(backupDone, len(a.Manifest), p)
+	return nil
+}
+
+func enterNewPassphrase() (string, error) {
+	raw, err := log.PromptSecret(passphraseNewPrompt)
+	if err != nil {
+		return "", err
+	}
+	if len(raw) < minSecretLen {
+		return "", ErrPassphraseTooShort
+	}
+
+	rawAgain, err := log.PromptSecret(passphraseNewAgainPrompt)
+	if err != nil {
+		return "", err
+	}
+	if raw != rawAgain {
+		return "", ErrPassphraseMismatch
+	}
+	return raw, nil
+}
+
+func defaultBackupFilename() string {
+	return fmt.Sprintf("spa-backup-%s.txt", time.Now().Format("20060102"))
+}
+
+// backupCmd represents the backup command
+var backupCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Use:                   "backup [-o <FILE>]",
+	Short:                 "Back up key, salt, and pepper",
+	Long: `
+Bundle your key, salt, and pepper files into a single archive encrypted with
+a passphrase.
+
+The archive carries a manifest with the checksum of each file. Use
+'spa restore' to verify and restore the files from the archive.
+
+Your secret is not included. You still need your secret to use the restored
+files.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := backup()
+		exit(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+
+	backupCmd.Flags().StringVarP(&cfg.Backup.File, "out-file", "o", defaultBackupFilename(), "backup file to write")
+	backupCmd.Flags().StringVar(&cfg.Backup.Passphrase, "passphrase", "", "passphrase for encrypting the backup")
+
+	backupCmd.Flags().MarkHidden("passphrase")
+}
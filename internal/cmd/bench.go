@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/gostones/spa/internal"
+	"github.com/gostones/spa/internal/format"
+	"github.com/gostones/spa/internal/log"
+	"github.com/gostones/spa/internal/sec"
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultBenchRuns   = 1
+	defaultBenchTarget = 5 * time.Second
+)
+
+const (
+	benchMessage = `Timing the password hashing algorithms, this could take a minute...
+`
+	benchRecommend = `
+Recommended profile for a command within %v: %s
+`
+	benchNone = `
+None of the profiles generates passwords within %v on this machine.
+`
+)
+
+// estimateProfile estimates the time of each stage from the average time of
+// the algorithms, as SPA picks one of them for each round. The lanes of the
+// profile run at the same time on up to cpus.
+func estimateProfile(p sec.KDFProfile, timings []sec.AlgoTiming, cpus int) internal.BenchProfile {
+	var total time.Duration
+	var algorithms []internal.BenchAlgorithm
+	for _, v := range timings {
+		total += v.Duration
+		algorithms = append(algorithms, internal.BenchAlgorithm{Name: v.Name, Duration: v.Duration})
+	}
+	var mean time.Duration
+	if len(timings) > 0 {
+		mean = total / time.Duration(len(timings))
+	}
+
+	lanes := p.LaneCount()
+	if cpus < 1 {
+		cpus = 1
+	}
+	rounds := (lanes + cpus - 1) / cpus
+	// wall time on this machine
+	wall := func(iteration int) time.Duration {
+		return time.Duration(p.LaneIteration(iteration)*rounds) * mean
+	}
+	// time of all iterations one after another
+	work := func(iteration int) time.Duration {
+		return time.Duration(p.LaneIteration(iteration)*lanes) * mean
+	}
+
+	r := internal.BenchProfile{
+		Profile:    p.Name,
+		Algorithms: algorithms,
+		Salt:       wall(p.SaltIteration),
+		Secret:     wall(p.SecretIteration),
+		KeyGen:     wall(p.KeyGenIteration),
+		Crypt:      wall(p.CryptIteration),
+	}
+	// the secret is hashed into two keys at the same time, one decrypts the
+	// pepper and the other generates the passwords.
+	r.Command = r.Secret + r.Crypt + r.KeyGen
+	// an attacker with the salt and pepper files checks each guess of the
+	// secret by decrypting the pepper. Lanes do not lower the cost as the
+	// attacker's CPUs are busy with other guesses.
+	r.Guess = work(p.SecretIteration) + work(p.CryptIteration)
+	r.GuessMemory = p.Memory()
+	return r
+}
+
+// recommendProfile returns the profile most costly to guess that generates
+// passwords within the target, or an empty string if none does.
+func recommendProfile(profiles []internal.BenchProfile, target time.Duration) string {
+	var best *internal.BenchProfile
+	for i, p := range profiles {
+		if p.Profile == sec.FastCIKDFProfile || p.Command > target {
+			continue
+		}
+		if best == nil || p.Guess > best.Guess {
+			best = &profiles[i]
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return best.Profile
+}
+
+func bench() error {
+	names := sec.KDFProfileNames()
+	if cfg.Bench.Profile != "" {
+		names = []string{cfg.Bench.Profile}
+	}
+
+	// the profile of the salt file, if any, is marked as current
+	var current string
+	if p := saltFilename(); checkFile(p) {
+		if _, profile, err := readSaltFile(p); err == nil {
+			current = profile
+			if current == "" {
+				current = sec.LegacyKDFProfile
+			}
+		}
+	}
+
+	log.Infof(benchMessage)
+
+	result := &internal.BenchResult{
+		Target: cfg.Bench.Target,
+	}
+	for _, name := range names {
+		p, _ := sec.LookupKDFProfile(name)
+		timings, err := sec.BenchKDFProfile(p, hashKeyLen, cfg.Bench.Runs)
+		if err != nil {
+			return err
+		}
+		r := estimateProfile(p, timings, runtime.NumCPU())
+		r.Current = name == current
+		result.Profiles = append(result.Profiles, r)
+	}
+	result.Recommended = recommendProfile(result.Profiles, cfg.Bench.Target)
+
+	printResult(result, func() {
+		format.FprintTab(os.Stderr, result)
+		if result.Recommended == "" {
+			log.Infof(benchNone, cfg.Bench.Target)
+			return
+		}
+		log.Infof(benchRecommend, cfg.Bench.Target, result.Recommended)
+	})
+	return nil
+}
+
+func validateBenchFlags(cmd *cobra.Command, args []string) error {
+	if cfg.Bench.Profile != "" {
+		if _, ok := sec.LookupKDFProfile(cfg.Bench.Profile); !ok {
+			return internal.NewUsageErrorf("unknown KDF profile: %q. valid profiles: %s", cfg.Bench.Profile, strings.Join(sec.KDFProfileNames(), ", "))
+		}
+	}
+	if cfg.Bench.Runs < 1 {
+		return internal.NewUsageErrorf("invalid runs: %v", cfg.Bench.Runs)
+	}
+	if cfg.Bench.Target <= 0 {
+		return internal.NewUsageErrorf("invalid target: %v", cfg.Bench.Target)
+	}
+	return nil
+}
+
+// benchCmd represents the bench command
+var benchCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Use:                   "bench [--profile <NAME>] [--runs <N>] [--target <DURATION>]",
+	Short:                 "Time the key derivation on this machine",
+	Long: `
+Time each password hashing algorithm of SPA with the work factors of the KDF
+profiles and estimate how long each stage takes on this machine:
+
+SALT    hashing the salt, once by 'spa salt save'
+SECRET  hashing the secret, each time it is entered
+KEYGEN  deriving the keys of a site, e.g. by 'spa pwd'
+CRYPT   encrypting or decrypting the pepper
+
+COMMAND is the time of a command generating passwords, e.g. 'spa pwd'.
+
+GUESS and MEMORY are the time and memory an attacker who has your salt and
+pepper files needs to check each guess of your secret on the same hardware.
+The time may be lower on dedicated hardware, but the memory is not.
+
+The lanes of v2-parallel run at the same time on a machine with enough CPUs,
+which cuts the time of a command but not the cost of a guess.
+
+The profile of your salt file is marked with *. The recommended profile is the
+most costly to guess that runs a command within --target. It is used by
+'spa salt save --kdf-profile'.
+`,
+	Args: validateBenchFlags,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := bench()
+		exit(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(benchCmd)
+
+	benchCmd.Flags().StringVar(&cfg.Bench.Profile, "profile", "", fmt.Sprintf("time only this KDF profile: %s", strings.Join(sec.KDFProfileNames(), ", ")))
+	benchCmd.Flags().IntVar(&cfg.Bench.Runs, "runs", defaultBenchRuns, "number of runs of each algorithm to average")
+	benchCmd.Flags().DurationVar(&cfg.Bench.Target, "target", defaultBenchTarget, "acceptable time of a command generating passwords")
+}
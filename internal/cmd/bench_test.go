@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gostones/spa/internal"
+	"github.com/gostones/spa/internal/sec"
+)
+
+func TestEstimateProfile(t *testing.T) {
+	p, _ := sec.LookupKDFProfile(sec.LegacyKDFProfile)
+	timings := []sec.AlgoTiming{
+		{Name: "scrypt", Duration: 100 * time.Millisecond},
+		{Name: "pbkdf2", Duration: 200 * time.Millisecond},
+		{Name: "argon2id", Duration: 300 * time.Millisecond},
+	}
+	r := estimateProfile(p, timings, 4)
+
+	mean := 200 * time.Millisecond
+	tests := []struct {
+		name string
+		got  time.Duration
+		want time.Duration
+	}{
+		{"salt", r.Salt, time.Duration(p.SaltIteration) * mean},
+		{"secret", r.Secret, time.Duration(p.SecretIteration) * mean},
+		{"keygen", r.KeyGen, time.Duration(p.KeyGenIteration) * mean},
+		{"crypt", r.Crypt, time.Duration(p.CryptIteration) * mean},
+		{"command", r.Command, time.Duration(p.SecretIteration+p.KeyGenIteration+p.CryptIteration) * mean},
+		{"guess", r.Guess, time.Duration(p.SecretIteration+p.CryptIteration) * mean},
+	}
+	for _, tc := range tests {
+		if tc.got != tc.want {
+			t.Fatalf("[%v] got: %v want: %v", tc.name, tc.got, tc.want)
+		}
+	}
+	if len(r.Algorithms) != len(timings) {
+		t.Fatalf("got: %v want: %v", len(r.Algorithms), len(timings))
+	}
+}
+
+func TestEstimateProfileLanes(t *testing.T) {
+	p, _ := sec.LookupKDFProfile(sec.ParallelKDFProfile)
+	timings := []sec.AlgoTiming{
+		{Name: "scrypt", Duration: 100 * time.Millisecond},
+	}
+	legacy, _ := sec.LookupKDFProfile(sec.LegacyKDFProfile)
+	want := estimateProfile(legacy, timings, 4)
+
+	// 4 lanes of 3 iterations, one for each algorithm
+	mean := 100 * time.Millisecond
+	tests := []struct {
+		cpus   int
+		secret time.Duration
+	}{
+		{1, 12 * mean},
+		{2, 6 * mean},
+		{4, 3 * mean},
+		{8, 3 * mean},
+	}
+	for i, tc := range tests {
+		r := estimateProfile(p, timings, tc.cpus)
+		if r.Secret != tc.secret {
+			t.Fatalf("[%v] got: %v want: %v", i, r.Secret, tc.secret)
+		}
+		// not lower than that of the legacy profile
+		if r.Guess < want.Guess {
+			t.Fatalf("[%v] got: %v want: >= %v", i, r.Guess, want.Guess)
+		}
+	}
+}
+
+func TestRecommendProfile(t *testing.T) {
+	profiles := []internal.BenchProfile{
+		{Profile: sec.FastCIKDFProfile, Command: time.Millisecond, Guess: time.Millisecond},
+		{Profile: "paranoid", Command: 12 * time.Second, Guess: 9 * time.Second},
+		{Profile: sec.LegacyKDFProfile, Command: 4 * time.Second, Guess: 2 * time.Second},
+	}
+	tests := []struct {
+		target time.Duration
+		want   string
+	}{
+		{time.Second, ""},
+		{5 * time.Second, sec.LegacyKDFProfile},
+		{12 * time.Second, "paranoid"},
+		{time.Minute, "paranoid"},
+	}
+	for i, tc := range tests {
+		got := recommendProfile(profiles, tc.target)
+		if got != tc.want {
+			t.Fatalf("[%v] got: %v want: %v", i, got, tc.want)
+		}
+	}
+}
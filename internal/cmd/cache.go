@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gostones/spa/internal/keyring"
+	"github.com/gostones/spa/internal/log"
+	"github.com/gostones/spa/internal/sec"
+)
+
+// The seeds of the keys derived from the secret are cached in the keyring by
+// spa unlock, encrypted with a random session key. The session key is kept in
+// a file, in the runtime directory if there is one, so that the keyring alone
+// can not be used to generate passwords.
+
+const (
+	defaultCacheTTL = 15 * time.Minute
+	sessionKeyLen   = 32
+
+	cachePrefix = "spa:"
+)
+
+// newKeyring is replaced in tests.
+var newKeyring = keyring.Detect
+
+func sessionFilename() string {
+	if envName := os.Getenv(spaSessionFileEnv); envName != "" {
+		return envName
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "spa-session")
+	}
+	return filepath.Join(cfg.BaseDir, "session")
+}
+
+func readSessionKey() ([]byte, error) {
+	b, err := ioutil.ReadFile(sessionFilename())
+	if err != nil {
+		return nil, err
+	}
+	return decodeKey(b)
+}
+
+// writeSessionKey reuses the session key if there is one so that the seeds of
+// other salts stay unlocked.
+func writeSessionKey() ([]byte, error) {
+	if key, err := readSessionKey(); err == nil && len(key) == sessionKeyLen {
+		return key, nil
+	}
+	key, err := sec.RandomBytes(sessionKeyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	file := sessionFilename()
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return nil, err
+	}
+	perm := os.FileMode(0600)
+	if err := ioutil.WriteFile(file, []byte(encodeKey(key)), perm); err != nil {
+		return nil, err
+	}
+	return key, os.Chmod(file, perm)
+}
+
+// cacheName returns the name of the seeds of the salt and key in the keyring,
+// which reveals nothing about them without the session key.
+func cacheName(session, key, salt []byte) string {
+	var b []byte
+	b = append(b, sec.CurrentKDFProfile().Name...)
+	b = append(b, salt...)
+	b = append(b, key...)
+	defer sec.Wipe(b)
+	return cachePrefix + hex.EncodeToString(sec.HMAC(session, b)[:16])
+}
+
+// cacheSeeds stores the seeds in the keyring until the ttl.
+func cacheSeeds(key, salt, seeds []byte, ttl time.Duration) (keyring.Keyring, error) {
+	k, err := newKeyring()
+	if err != nil {
+		return nil, err
+	}
+	session, err := writeSessionKey()
+	if err != nil {
+		return nil, err
+	}
+	defer sec.Wipe(session)
+
+	name := cacheName(session, key, salt)
+	b, err := sec.Seal(session, seeds, []byte(name))
+	if err != nil {
+		return nil, err
+	}
+	return k, k.Set(name, b, ttl)
+}
+
+// cachedSeeds returns the seeds cached by spa unlock, or nil if they are not
+// found or can not be read. The cache is optional; errors are only logged.
+func cachedSeeds(key, salt []byte) *sec.Buffer {
+	session, err := readSessionKey()
+	if err != nil {
+		return nil
+	}
+	defer sec.Wipe(session)
+
+	k, err := newKeyring()
+	if err != nil {
+		log.Debugf("keyring: %v\n", err)
+		return nil
+	}
+	name := cacheName(session, key, salt)
+	b, err := k.Get(name)
+	if err != nil {
+		if err != keyring.ErrNotFound {
+			log.Debugf("keyring %s: %v\n", k.Name(), err)
+		}
+		return nil
+	}
+	seeds, err := sec.Open(session, b, []byte(name))
+	if err != nil || len(seeds) != 2*hashKeyLen {
+		log.Debugf("keyring %s: invalid seeds of %q\n", k.Name(), name)
+		k.Delete(name)
+		return nil
+	}
+	return sec.NewBufferFrom(seeds)
+}
+
+// clearCache removes the seeds of the salt and key, if any, and the session
+// key, so that the seeds of other salts can not be read either.
+func clearCache(key, salt []byte) error {
+	session, err := readSessionKey()
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer sec.Wipe(session)
+
+	if salt != nil {
+		if k, err := newKeyring(); err == nil {
+			if err := k.Delete(cacheName(session, key, salt)); err != nil && err != keyring.ErrNotFound {
+				return err
+			}
+		}
+	}
+	return os.Remove(sessionFilename())
+}
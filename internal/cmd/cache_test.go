@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gostones/spa/internal/keyring"
+	"github.com/gostones/spa/internal/sec"
+)
+
+func TestCacheSeeds(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	session := filepath.Join(dir, "session")
+	defer os.Setenv(spaSessionFileEnv, os.Getenv(spaSessionFileEnv))
+	os.Setenv(spaSessionFileEnv, session)
+
+	k := keyring.NewMemory()
+	defer func(f func() (keyring.Keyring, error)) { newKeyring = f }(newKeyring)
+	newKeyring = func() (keyring.Keyring, error) {
+		return k, nil
+	}
+
+	key, _ := sec.RandomBytes(32)
+	salt, _ := sec.RandomBytes(hashKeyLen * 4)
+	other, _ := sec.RandomBytes(hashKeyLen * 4)
+	seeds, _ := sec.RandomBytes(2 * hashKeyLen)
+
+	if s := cachedSeeds(key, salt); s != nil {
+		t.Fatalf("got: %x want: nil", s.Bytes())
+	}
+
+	if _, err := cacheSeeds(key, salt, seeds, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cacheSeeds(key, other, seeds, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key  []byte
+		salt []byte
+		ok   bool
+	}{
+		{key, salt, true},
+		{key, other, true},
+		{other[:32], salt, false},
+	}
+	for i, tc := range tests {
+		s := cachedSeeds(tc.key, tc.salt)
+		if (s != nil) != tc.ok {
+			t.Fatalf("[%v] got: %v want: %v", i, s != nil, tc.ok)
+		}
+		if s != nil && !bytes.Equal(s.Bytes(), seeds) {
+			t.Fatalf("[%v] got: %x want: %x", i, s.Bytes(), seeds)
+		}
+	}
+
+	// the seeds of other salts can not be read without the session key
+	if err := clearCache(key, salt); err != nil {
+		t.Fatal(err)
+	}
+	if checkFile(session) {
+		t.Fatalf("session key %q not removed", session)
+	}
+	for i, s := range [][]byte{salt, other} {
+		if got := cachedSeeds(key, s); got != nil {
+			t.Fatalf("[%v] got: %x want: nil", i, got.Bytes())
+		}
+	}
+	if err := clearCache(key, salt); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExpandSeeds(t *testing.T) {
+	defer useKDFProfile(sec.LegacyKDFProfile)
+	if err := useKDFProfile(sec.FastCIKDFProfile); err != nil {
+		t.Fatal(err)
+	}
+	defer wipeSecrets()
+
+	raw := []byte("secret123")
+	key, err := sec.InitSPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	salt, _ := sec.RandomBytes(hashKeyLen * 4)
+
+	seeds, err := secretSeeds(raw, key, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer seeds.Destroy()
+	got, err := expandSeeds(seeds.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the same keys as those of spakdf
+	hash := sec.SPIHash(raw, key)
+	for i, v := range split2(hash) {
+		idx := sec.FNV(v, uint32(len(salt)-hashKeyLen))
+		want, err := spakdf(context.Background(), v, salt[idx:idx+hashKeyLen], sec.CurrentKDFProfile().SecretIteration, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got[i], want.Bytes()) {
+			t.Fatalf("[%v] got: %x want: %x", i, got[i][:8], want.Bytes()[:8])
+		}
+		want.Destroy()
+	}
+}
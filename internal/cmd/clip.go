@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal"
+	"github.com/gostones/spa/internal/clipboard"
+	"github.com/gostones/spa/internal/log"
+)
+
+const defaultClipClear = 45 * time.Second
+
+const (
+	clipCopied = `Password [%04v] for %q copied to the %s clipboard.
+`
+	clipClearing = `It will be cleared in %v, press Ctrl-C to clear it now.
+`
+	clipCleared = `Clipboard cleared.
+`
+	clipKept = `Clipboard not cleared, it no longer holds the password.
+`
+)
+
+// newClipboard is replaced in tests.
+var newClipboard = clipboard.Detect
+
+// copyCandidate copies the candidate to the clipboard and clears it after
+// the timeout, or when interrupted.
+func copyCandidate(du string, c internal.Candidate, d time.Duration) error {
+	cb, err := newClipboard()
+	if err != nil {
+		return err
+	}
+	if err := cb.Write(c.Value); err != nil {
+		return err
+	}
+	log.Infof(clipCopied, c.PIN, du, cb.Name())
+	if d <= 0 {
+		return nil
+	}
+
+	log.Infof(clipClearing, d)
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sig)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-sig:
+			close(done)
+		case <-time.After(d):
+		}
+	}()
+
+	cleared, err := clipboard.ClearAfter(cb, c.Value, d, done)
+	if err != nil {
+		return err
+	}
+	if cleared {
+		log.Infof(clipCleared)
+	} else {
+		log.Infof(clipKept)
+	}
+	return nil
+}
+
+func validateClipFlags() error {
+	if !cfg.Clip.Copy {
+		return nil
+	}
+	if cfg.Pin < 0 {
+		return internal.NewUsageError("--copy requires a PIN, please provide one with --pin")
+	}
+	if cfg.Clip.Clear < 0 {
+		return internal.NewUsageErrorf("invalid --clear: %v", cfg.Clip.Clear)
+	}
+	return nil
+}
+
+func addClipFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&cfg.Clip.Copy, "copy", false, "copy the password of the PIN to the clipboard instead of printing it.")
+	cmd.Flags().DurationVar(&cfg.Clip.Clear, "clear", defaultClipClear, "time to clear the clipboard after --copy if it still holds the password, 0 to keep it.")
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gostones/spa/internal"
+	"github.com/gostones/spa/internal/clipboard"
+)
+
+type testClipboard struct {
+	s string
+}
+
+func (c *testClipboard) Name() string {
+	return "test"
+}
+
+func (c *testClipboard) Read() (string, error) {
+	return c.s, nil
+}
+
+func (c *testClipboard) Write(s string) error {
+	c.s = s
+	return nil
+}
+
+func TestCopyCandidate(t *testing.T) {
+	cb := &testClipboard{s: "old"}
+	defer func(f func() (clipboard.Clipboard, error)) { newClipboard = f }(newClipboard)
+	newClipboard = func() (clipboard.Clipboard, error) {
+		return cb, nil
+	}
+
+	c := internal.Candidate{PIN: 1, Value: "secret"}
+	tests := []struct {
+		clear time.Duration
+		want  string
+	}{
+		{0, "secret"},
+		{time.Millisecond, ""},
+	}
+	for i, test := range tests {
+		if err := copyCandidate("example.com:", c, test.clear); err != nil {
+			t.Fatalf("[%v] err: %v", i, err)
+		}
+		if cb.s != test.want {
+			t.Fatalf("[%v] got: %q want: %q", i, cb.s, test.want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal"
+	"github.com/gostones/spa/internal/log"
+	"github.com/gostones/spa/internal/sec"
+)
+
+const (
+	minCodeLength     = 4
+	maxCodeLength     = 16
+	defaultCodeLength = 6
+)
+
+// codebook of numeric codes
+const codeDigits = "0123456789"
+
+func genCode(cmd *cobra.Command) error {
+	s, err := decryptSafe(cfg.Secret.Foil)
+	if err != nil {
+		return err
+	}
+	if s.Codes == nil {
+		s.Codes = make(map[string]internal.CodeConfig)
+	}
+
+	cfg.Domain, cfg.User = s.resolve(cfg.Domain, cfg.User)
+	du := domainUser(cfg.Domain, cfg.User)
+	c, ok := s.Codes[du]
+	if !ok {
+		// a code is added for a saved site, or a new one only if requested.
+		if _, saved := s.Data[du]; !saved && !cfg.Site.New {
+			return siteNotFound(s, du)
+		}
+		b, err := sec.RandomBytes(autoPepperSize)
+		if err != nil {
+			return err
+		}
+		c = internal.CodeConfig{
+			Pepper: sec.Base64(b),
+			Length: defaultCodeLength,
+		}
+	}
+
+	// default/old values if no new values are provided on command line.
+	if cmd.Flags().Changed("length") {
+		c.Length = cfg.Code.Length
+	}
+	if cmd.Flags().Changed("note") {
+		c.Note = cfg.Code.Note
+	}
+	if cmd.Flags().Changed("pepper") {
+		pepper := cfg.Code.Pepper
+		if pepper == "auto" {
+			log.Infof(autoPepperGeneration)
+
+			b, err := sec.RandomBytes(autoPepperSize)
+			if err != nil {
+				return err
+			}
+			pepper = sec.Base64(b)
+		}
+
+		log.Infof(savePepperOverrite, cfg.Domain, c.Pepper, pepper)
+		choice, err := log.Confirm(savePepperPrompt)
+		if err != nil {
+			return err
+		}
+		if choice == "n" {
+			return nil
+		}
+		c.Pepper = pepper
+	}
+
+	count := cfg.Count
+	if cfg.Pin >= 0 {
+		count = cfg.Pin + 1
+	}
+
+	codes, err := codeCandidates(cfg.Domain, cfg.User, c, count)
+	if err != nil {
+		return err
+	}
+
+	print := func(pin int) {
+		s := fmt.Sprintf("[%04v] %s", pin, codes[pin])
+		log.Infoln(s)
+	}
+
+	if cfg.Pin < 0 {
+		for i := 0; i < len(codes); i++ {
+			print(i)
+		}
+	} else {
+		print(cfg.Pin)
+	}
+
+	s.Codes[du] = c
+	return encryptSafe(cfg.Secret.Foil, s)
+}
+
+// codeCandidates generates count numeric codes for the site with the given config.
+func codeCandidates(domain, user string, c internal.CodeConfig, count int) ([]string, error) {
+	domain, user = siteOrigin(domain, user, c.Origin)
+	g := sec.CodeGen(codeDigits, cfg.Secret.Stock, cfg.Salt.Hash, hashKeyLen, sec.CurrentKDFProfile().KeyGenIteration)
+	return g(domain, user, c.Pepper, count, c.Length)
+}
+
+func validateCodeFlags(cmd *cobra.Command, args []string) error {
+	if cfg.Domain == "" {
+		return fmt.Errorf("domain name is required")
+	}
+
+	if cfg.Count < 1 {
+		return internal.NewUsageErrorf("invalid count: %v", cfg.Count)
+	}
+
+	if cfg.Code.Length < minCodeLength || cfg.Code.Length > maxCodeLength {
+		return fmt.Errorf("invalid length: %v. valid range [%v, %v]", cfg.Code.Length, minCodeLength, maxCodeLength)
+	}
+
+	return nil
+}
+
+// codeCmd represents the code command
+var codeCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Use:                   "code -d <DOMAIN NAME> [-p <PIN>] [--length <N>] [--new]",
+	Short:                 "Generate numeric codes",
+	Long: fmt.Sprintf(`
+Generate a set of candidate numeric codes, e.g. phone unlock codes, card PINs,
+and voicemail codes.
+
+Codes are kept apart from passwords; each site has its own pepper and length
+for codes, saved after the first run. Provide --new for the first run of a
+site that is not saved yet. Digits are evenly distributed.
+
+A minimum of %v digits is allowed. Short codes are easy to guess; use them
+only where the device or service limits the number of attempts.
+`, minCodeLength),
+	Args: validateCodeFlags,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := checkSaltSecret(); err != nil {
+			exit(err)
+		}
+
+		err := genCode(cmd)
+		exit(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(codeCmd)
+
+	codeCmd.Flags().StringVar(&cfg.Salt.Raw, "salt", "", "specify a salt to use, whitespaces are ignored. default: saved in ~/.spa/salt")
+	codeCmd.Flags().StringVarP(&cfg.Secret.Raw, "secret", "s", "", "your secret")
+
+	codeCmd.Flags().VarP(newDomainValue("", &cfg.Domain), "domain", "d", "domain name of the web site or a label, e.g. phone. case insensitive.")
+	codeCmd.Flags().StringVarP(&cfg.User, "user", "u", "", "optional username, card, or ID. provide a value if you have multiple codes for the same site.")
+	codeCmd.Flags().StringVar(&cfg.Code.Pepper, "pepper", "", "recommended text to generate a different code. enter 'auto' if you want it randomly generated.")
+	codeCmd.Flags().StringVar(&cfg.Code.Note, "note", "", "attach a note for information only, note does not alter code generation.")
+	codeCmd.Flags().IntVar(&cfg.Code.Length, "length", defaultCodeLength, fmt.Sprintf("optional number of digits, minimum %v maximum %v.", minCodeLength, maxCodeLength))
+
+	codeCmd.Flags().IntVar(&cfg.Count, "count", defaultMaxPIN, "optional number of codes to generate")
+	codeCmd.Flags().VarP(newPinValue(-1, &cfg.Pin), "pin", "p", "optional number to pick the code, the full list will be shown if not provided.")
+	addNewSiteFlag(codeCmd)
+
+	codeCmd.MarkFlagRequired("domain")
+
+	codeCmd.Flags().MarkHidden("salt")
+	codeCmd.Flags().MarkHidden("secret")
+}
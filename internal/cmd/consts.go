@@ -12,11 +12,6 @@ const (
 	hashKeyLen     = 64
 	masterKeyCount = 1024
 
-	saltIteration   = 1024
-	secretIteration = 8
-	keyGenIteration = 8
-	cryptIteration  = 6
-
 	// 1 year < 2^26 seconds
 	// total number of pc < 2^34 ( every one owns 2 devices 2^33 * 2)
 	// letter (2^6) vs byte (2^8)
@@ -33,14 +28,17 @@ const (
 )
 
 const (
-	spaSecretEnv = "SPA_SECRET"
-	spaPinEnv    = "SPA_PIN"
+	spaSecretEnv   = "SPA_SECRET"
+	spaAskPassEnv  = "SPA_ASKPASS"
+	spaPinentryEnv = "SPA_PINENTRY"
+	spaPinEnv      = "SPA_PIN"
 
 	spaConfigEnv = "SPA_CONFIG"
 
-	spaSaltFileEnv   = "SPA_SALT_FILE"
-	spaPepperFileEnv = "SPA_PEPPER_FILE"
-	spaKeyFileEnv    = "SPA_KEY_FILE"
+	spaSaltFileEnv    = "SPA_SALT_FILE"
+	spaPepperFileEnv  = "SPA_PEPPER_FILE"
+	spaKeyFileEnv     = "SPA_KEY_FILE"
+	spaSessionFileEnv = "SPA_SESSION_FILE"
 )
 
 const defaultDir = ".spa"
@@ -69,6 +67,8 @@ You should consider backing up your pepper in case you lose access to this compu
 
 var ErrSecretTooShort = fmt.Errorf("secret is too short. minimum characters required: %v", minSecretLen)
 var ErrSecretMismatch = fmt.Errorf("secret does not match! Please try again")
+var ErrPassphraseMismatch = fmt.Errorf("passphrase does not match! Please try again")
+var ErrPassphraseTooShort = fmt.Errorf("passphrase is too short. minimum characters required: %v", minSecretLen)
 
 func pepperFilename() string {
 	name := filepath.Join(cfg.BaseDir, "pepper")
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal/sec"
+)
+
+func decryptFile() error {
+	id, _, err := ageIdentity(cfg.Age.Label)
+	if err != nil {
+		return err
+	}
+
+	in, err := openInput(cfg.Age.Input)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, remove, err := createOutput(cfg.Age.Output)
+	if err != nil {
+		return err
+	}
+	if err := sec.AgeDecrypt(out, in, id); err != nil {
+		out.Close()
+		remove()
+		return err
+	}
+	return out.Close()
+}
+
+// decryptCmd represents the decrypt command
+var decryptCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Use:                   "decrypt [-i <FILE>] [-o <FILE>]",
+	Short:                 "Decrypt file",
+	Long: `
+Decrypt the age encrypted file with the identity derived from your secret for
+the label.
+
+The standard input and output are used if no file is given.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := requireSecret(); err != nil {
+			exit(err)
+		}
+
+		err := decryptFile()
+		exit(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(decryptCmd)
+
+	addAgeFlags(decryptCmd)
+	decryptCmd.Flags().StringVarP(&cfg.Age.Input, "input", "i", stdio, "file to decrypt.")
+	decryptCmd.Flags().StringVarP(&cfg.Age.Output, "out-file", "o", stdio, "decrypted file.")
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal/sec"
+)
+
+// parseRecipients decodes the recipients, self is included if none is given.
+func parseRecipients(list []string, self []byte) ([][]byte, error) {
+	if len(list) == 0 {
+		return [][]byte{self}, nil
+	}
+	var recipients [][]byte
+	for _, v := range list {
+		pub, err := sec.DecodeAgeRecipient(v)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, pub)
+	}
+	return recipients, nil
+}
+
+func encryptFile() error {
+	_, pub, err := ageIdentity(cfg.Age.Label)
+	if err != nil {
+		return err
+	}
+	recipients, err := parseRecipients(cfg.Age.Recipients, pub)
+	if err != nil {
+		return err
+	}
+
+	in, err := openInput(cfg.Age.Input)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, remove, err := createOutput(cfg.Age.Output)
+	if err != nil {
+		return err
+	}
+	if err := sec.AgeEncrypt(out, in, recipients); err != nil {
+		out.Close()
+		remove()
+		return err
+	}
+	return out.Close()
+}
+
+// encryptCmd represents the encrypt command
+var encryptCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Use:                   "encrypt [-i <FILE>] [-o <FILE>] [-r <RECIPIENT>]...",
+	Short:                 "Encrypt file",
+	Long: `
+Encrypt the file in the age format to the recipient derived from your secret
+for the label, or to the given recipients.
+
+Recipients replace yours; run 'spa age' to show it if you want to include it.
+
+The standard input and output are used if no file is given.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := requireSecret(); err != nil {
+			exit(err)
+		}
+
+		err := encryptFile()
+		exit(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(encryptCmd)
+
+	addAgeFlags(encryptCmd)
+	encryptCmd.Flags().StringVarP(&cfg.Age.Input, "input", "i", stdio, "file to encrypt.")
+	encryptCmd.Flags().StringVarP(&cfg.Age.Output, "out-file", "o", stdio, "encrypted file.")
+	encryptCmd.Flags().StringArrayVarP(&cfg.Age.Recipients, "recipient", "r", nil, "optional age recipient, age1... may be repeated. default: yours for the label")
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gostones/spa/internal/sec"
+)
+
+func TestParseRecipients(t *testing.T) {
+	self := bytes.Repeat([]byte{1}, 32)
+	other := bytes.Repeat([]byte{2}, 32)
+	r, _ := sec.EncodeAgeRecipient(other)
+
+	tests := []struct {
+		list     []string
+		expected [][]byte
+		ok       bool
+	}{
+		{nil, [][]byte{self}, true},
+		{[]string{r}, [][]byte{other}, true},
+		{[]string{r, r}, [][]byte{other, other}, true},
+		{[]string{"age1invalid"}, nil, false},
+	}
+	for i, tc := range tests {
+		got, err := parseRecipients(tc.list, self)
+		if (err == nil) != tc.ok {
+			t.Fatalf("[%v] got: %v want ok: %v", i, err, tc.ok)
+		}
+		if len(got) != len(tc.expected) {
+			t.Fatalf("[%v] got: %x want: %x", i, got, tc.expected)
+		}
+		for j := range got {
+			if !bytes.Equal(got[j], tc.expected[j]) {
+				t.Fatalf("[%v] got: %x want: %x", i, got, tc.expected)
+			}
+		}
+	}
+}
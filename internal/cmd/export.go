@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal"
+	"github.com/gostones/spa/internal/log"
+)
+
+const exportPaperDone = `
+Paper backup saved in %q. Print or copy it by hand, then delete the file.
+`
+
+func exportPaper() error {
+	if !cfg.Backup.Paper {
+		return internal.NewUsageError("only paper export is supported, please specify --paper")
+	}
+
+	var sections []paperSection
+
+	b, err := ioutil.ReadFile(keyFilename())
+	if err != nil {
+		return err
+	}
+	key, err := decodeKey(b)
+	if err != nil {
+		return err
+	}
+	sections = append(sections, paperSection{Name: archiveKey, Data: key})
+
+	if p := pepperFilename(); checkFile(p) {
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		data, err := compactSafe(b)
+		if err != nil {
+			return err
+		}
+		sections = append(sections, paperSection{Name: archivePepper, Data: data})
+	}
+
+	if cfg.Backup.File == "" {
+		return writePaper(os.Stdout, sections)
+	}
+
+	f, err := os.OpenFile(cfg.Backup.File, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := writePaper(f, sections); err != nil {
+		return err
+	}
+	log.Infof(exportPaperDone, cfg.Backup.File)
+	return nil
+}
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Use:                   "export --paper [-o <FILE>]",
+	Short:                 "Export key and pepper for paper backup",
+	Long: `
+Export your key and pepper as lines of words for an offline recovery sheet.
+
+Each byte is written as a word; each line is numbered and ends with a checksum
+word so that a mistake in copying can be located. Use 'spa import --paper' to
+recreate the files from the sheet.
+
+The salt hash is not included. Keep the original salt text so that you can
+save it again with 'spa salt save'.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := exportPaper()
+		exit(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().BoolVar(&cfg.Backup.Paper, "paper", false, "export in word list format for writing down on paper")
+	exportCmd.Flags().StringVarP(&cfg.Backup.File, "out-file", "o", "", "optional file to write, default: standard output")
+}
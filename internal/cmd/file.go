@@ -1,16 +1,17 @@
 package cmd
 
 import (
-	"bytes"
 	"encoding/base64"
-	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/gostones/spa/internal"
+	"github.com/gostones/spa/internal/format"
 	"github.com/gostones/spa/internal/log"
 	"github.com/gostones/spa/internal/sec"
 )
@@ -18,81 +19,95 @@ import (
 type Safe struct {
 	Nonce []byte
 	Data  map[string]internal.PwdConfig
+	Codes map[string]internal.CodeConfig `json:",omitempty"`
+
+	// Aliases maps a domain:user to the domain:user of the record.
+	Aliases map[string]string `json:",omitempty"`
+
+	// History keeps the past configs of each site, oldest first.
+	History map[string][]internal.PwdRevision `json:",omitempty"`
 }
 
 func domainUser(domain, user string) string {
 	return strings.Join([]string{domain, user}, ":")
 }
 
-func readPepper(domain, user string) (map[string]internal.PwdConfig, error) {
+// readPepper reads the records of all sites. The domain and user of the config
+// are resolved to those of the record if they are an alias. A record is added
+// for a new site only if requested; close matches are suggested otherwise.
+func readPepper() (map[string]internal.PwdConfig, error) {
 	s, err := decryptSafe(cfg.Secret.Foil)
 	if err != nil {
 		return nil, err
 	}
 	peppers := s.Data
 
-	du := domainUser(domain, user)
+	cfg.Domain, cfg.User = s.resolve(cfg.Domain, cfg.User)
+	du := domainUser(cfg.Domain, cfg.User)
 	if _, ok := peppers[du]; !ok {
-		b, err := sec.RandomBytes(autoPepperSize)
+		if !cfg.Site.New {
+			return nil, siteNotFound(s, du)
+		}
+		c, err := newPepper()
 		if err != nil {
 			return nil, err
 		}
-		c := internal.PwdConfig{
-			Pepper: sec.Base64(b),
-			Length: defaultPwdLength,
-			Mask:   sec.EncloseEscape,
-		}
 		peppers[du] = c
 	}
 
 	return peppers, nil
 }
 
+// newPepper returns the default record of a new site with a random pepper.
+func newPepper() (internal.PwdConfig, error) {
+	b, err := sec.RandomBytes(autoPepperSize)
+	if err != nil {
+		return internal.PwdConfig{}, err
+	}
+	return internal.PwdConfig{
+		Pepper: sec.Base64(b),
+		Length: defaultPwdLength,
+		Mask:   sec.EncloseEscape,
+	}, nil
+}
+
 func writePepper(peppers map[string]internal.PwdConfig) error {
 	s, err := decryptSafe(cfg.Secret.Foil)
 	if err != nil {
 		return err
 	}
+	s.keepHistory(peppers, time.Now())
 	s.Data = peppers
 
 	return encryptSafe(cfg.Secret.Foil, s)
 }
 
-func pickKey(key, nonce []byte) []byte {
+func pickKey(key, nonce []byte, keyLen int) []byte {
 	hi := func(x, y []byte, max int) int {
 		b := sec.HMAC(x, y)
 		return int(sec.FNV(b, uint32(max)))
 	}
-	idx := hi(key[0:hashKeyLen], nonce, len(key)-hashKeyLen)
-	secret := key[idx : idx+hashKeyLen]
+	idx := hi(key[0:keyLen], nonce, len(key)-keyLen)
+	secret := key[idx : idx+keyLen]
 	return secret
 }
 
 func encryptSafe(key []byte, s *Safe) error {
-	nonce, err := sec.RandomBytes(hashKeyLen)
+	b, err := sealSafe(key, newSafeHeader(), s)
 	if err != nil {
 		return err
 	}
-	s.Nonce = nonce
-
-	data, err := json.Marshal(s)
-	if err != nil {
-		return err
-	}
-	secret := pickKey(key, nonce)
-	cipher, err := sec.Encrypt(secret, data, cryptIteration)
-	if err != nil {
-		return err
-	}
-	enc := base64.StdEncoding.EncodeToString(append(cipher, nonce...))
 
 	file := pepperFilename()
 	dir := filepath.Dir(file)
 	if err := os.MkdirAll(dir, 0700); err != nil {
 		return err
 	}
+	if err := backupSafe(file); err != nil {
+		return err
+	}
 	perm := os.FileMode(0600)
-	if err := ioutil.WriteFile(file, []byte(enc), perm); err != nil {
+	if err := ioutil.WriteFile(file, b, perm); err != nil {
 		return err
 	}
 	return os.Chmod(file, perm)
@@ -110,24 +125,31 @@ func decryptSafe(key []byte) (*Safe, error) {
 	if err != nil {
 		return nil, err
 	}
-	dec, err := base64.StdEncoding.DecodeString(string(b))
+	_, s, err := openSafe(key, b)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to open pepper file %q: %v", file, err)
 	}
+	return s, nil
+}
 
-	nonce := dec[len(dec)-hashKeyLen:]
-	cipher := dec[0 : len(dec)-hashKeyLen]
-	secret := pickKey(key, nonce)
-	data, err := sec.Decrypt(secret, cipher, cryptIteration)
+// backupSafe keeps a copy of a pepper file of an older version before it is
+// overwritten in the current format. Files are only migrated by the commands
+// that write the safe.
+func backupSafe(file string) error {
+	b, err := ioutil.ReadFile(file)
 	if err != nil {
-		return nil, err
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
 	}
-
-	var s Safe
-	if err := json.Unmarshal(data, &s); err != nil {
-		return nil, err
+	h, _, err := parseSafe(b)
+	if err != nil || h.Version >= safeVersion {
+		return nil
 	}
-	return &s, nil
+	bak := fmt.Sprintf("%s.v%v.bak", file, h.Version)
+	log.Infof(migrateSafeMessage, file, h.Version, safeVersion, bak)
+	return ioutil.WriteFile(bak, b, 0600)
 }
 
 func catPepperFile() error {
@@ -137,18 +159,6 @@ func catPepperFile() error {
 	}
 	peppers := s.Data
 
-	pretty := func(c internal.PwdConfig) ([]byte, error) {
-		b, err := json.Marshal(c)
-		if err != nil {
-			return nil, err
-		}
-		var out bytes.Buffer
-		if err := json.Indent(&out, b, "", "  "); err != nil {
-			return nil, err
-		}
-		return out.Bytes(), err
-	}
-
 	var keys []string
 	for k := range peppers {
 		keys = append(keys, k)
@@ -163,17 +173,32 @@ func catPepperFile() error {
 		return (domain == "" || strings.Contains(strings.ToLower(sa[0]), domain)) && (user == "" || strings.Contains(strings.ToLower(sa[1]), user))
 	}
 
+	result := &internal.SiteListResult{}
 	for _, k := range keys {
 		if filtering && !match(k) {
 			continue
 		}
 		v := peppers[k]
-		b, err := pretty(v)
-		if err != nil {
-			return nil
+		d, u := splitDomainUser(k)
+		result.Sites = append(result.Sites, internal.SiteResult{Domain: d, User: u, Config: &v})
+	}
+
+	var aliases []string
+	for k := range s.Aliases {
+		aliases = append(aliases, k)
+	}
+	sort.Strings(aliases)
+	for _, k := range aliases {
+		if filtering && !match(k) {
+			continue
 		}
-		log.Infof("%q: %s\n", k, string(b))
+		d, u := splitDomainUser(k)
+		result.Sites = append(result.Sites, internal.SiteResult{Domain: d, User: u, Alias: s.Aliases[k]})
 	}
+
+	printResult(result, func() {
+		format.FprintTab(os.Stderr, result)
+	})
 	return nil
 }
 
@@ -194,6 +219,14 @@ func readKey() ([]byte, error) {
 		return nil, err
 	}
 
+	return decodeKey(b)
+}
+
+func encodeKey(key []byte) string {
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func decodeKey(b []byte) ([]byte, error) {
 	return base64.StdEncoding.DecodeString(string(b))
 }
 
@@ -205,7 +238,7 @@ func writeKey(newKey []byte) error {
 		return err
 	}
 
-	enc := base64.StdEncoding.EncodeToString(newKey)
+	enc := encodeKey(newKey)
 	perm := os.FileMode(0600)
 	if err := ioutil.WriteFile(file, []byte(enc), perm); err != nil {
 		return err
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal"
+	"github.com/gostones/spa/internal/log"
+)
+
+func importPaper() error {
+	if !cfg.Backup.Paper {
+		return internal.NewUsageError("only paper import is supported, please specify --paper")
+	}
+
+	f, err := os.Open(cfg.Backup.File)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sections, err := readPaper(f)
+	if err != nil {
+		return err
+	}
+
+	names := archiveFilenames()
+	files := make(map[string][]byte)
+	for _, s := range sections {
+		switch s.Name {
+		case archiveKey:
+			files[names[s.Name]] = []byte(encodeKey(s.Data))
+		case archivePepper:
+			b, err := expandSafe(s.Data)
+			if err != nil {
+				return fmt.Errorf("[%s] %v", s.Name, err)
+			}
+			files[names[s.Name]] = b
+		default:
+			return fmt.Errorf("unknown section: %q", s.Name)
+		}
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no key or pepper found in %q", cfg.Backup.File)
+	}
+
+	var existing []string
+	for file := range files {
+		if checkFile(file) {
+			existing = append(existing, file)
+		}
+	}
+	if len(existing) > 0 {
+		sort.Strings(existing)
+		var list string
+		for _, v := range existing {
+			list += fmt.Sprintf("  %s\n", v)
+		}
+		log.Infof(restoreOverwrite, list)
+		choice, err := log.Confirm(restorePrompt)
+		if err != nil {
+			return err
+		}
+		if choice == "n" {
+			return nil
+		}
+	}
+
+	for file, data := range files {
+		if err := writeFile(file, data); err != nil {
+			return err
+		}
+	}
+
+	log.Infof(restoreDone)
+	return nil
+}
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Use:                   "import --paper -i <FILE>",
+	Short:                 "Import key and pepper from paper backup",
+	Long: `
+Recreate your key and pepper files from the words typed in from a recovery
+sheet exported by 'spa export --paper'.
+
+The line numbers and checksum words are verified; a word may be entered by its
+first four letters.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := importPaper()
+		exit(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+
+	importCmd.Flags().BoolVar(&cfg.Backup.Paper, "paper", false, "import from word list format")
+	importCmd.Flags().StringVarP(&cfg.Backup.File, "input", "i", "", "file containing the typed in words")
+
+	importCmd.MarkFlagRequired("input")
+}
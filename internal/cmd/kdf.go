@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal/sec"
+)
+
+// the profile separator of the salt file content, e.g. paranoid:<HEX>
+const kdfProfileSep = ":"
+
+// useKDFProfile sets the work factors of the key derivation, an empty name is
+// the legacy profile.
+func useKDFProfile(name string) error {
+	if name == "" {
+		name = sec.LegacyKDFProfile
+	}
+	p, ok := sec.LookupKDFProfile(name)
+	if !ok {
+		return fmt.Errorf("unknown KDF profile: %q. valid profiles: %s", name, strings.Join(sec.KDFProfileNames(), ", "))
+	}
+	sec.SetKDFProfile(p)
+	cfg.Salt.Profile = name
+	return nil
+}
+
+// kdfProfileTag returns the name recorded in files, empty for the legacy
+// profile so that the files stay the same as before profiles.
+func kdfProfileTag() string {
+	name := sec.CurrentKDFProfile().Name
+	if name == sec.LegacyKDFProfile {
+		return ""
+	}
+	return name
+}
+
+// checkKDFProfile checks that the file was written with the current profile.
+func checkKDFProfile(what, tag string) error {
+	if tag != kdfProfileTag() {
+		if tag == "" {
+			tag = sec.LegacyKDFProfile
+		}
+		return fmt.Errorf("%s was written with KDF profile %q, but your salt uses %q", what, tag, sec.CurrentKDFProfile().Name)
+	}
+	return nil
+}
+
+func addKDFProfileFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&cfg.Salt.Profile, "kdf-profile", sec.LegacyKDFProfile, fmt.Sprintf("work factors of the key derivation: %s. fast-ci is for tests only.", strings.Join(sec.KDFProfileNames(), ", ")))
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal/log"
+)
+
+const lockDone = `Locked.
+`
+
+func lock() error {
+	// the seeds of the salt file are removed from the keyring. Those of other
+	// salts can not be read without the session key, and expire.
+	var key, salt []byte
+	if p := saltFilename(); checkFile(p) {
+		hash, profile, err := readSaltFile(p)
+		if err != nil {
+			return err
+		}
+		if err := useKDFProfile(profile); err != nil {
+			return err
+		}
+		salt = hash
+		defer wipe(salt)
+	}
+	if checkFile(keyFilename()) {
+		b, err := readKey()
+		if err != nil {
+			return err
+		}
+		key = b
+	}
+
+	if err := clearCache(key, salt); err != nil {
+		return err
+	}
+	log.Infof(lockDone)
+	return nil
+}
+
+// lockCmd represents the lock command
+var lockCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Use:                   "lock",
+	Short:                 "Lock your secret unlocked by 'spa unlock'",
+	Long: `
+Remove your hashed secret kept by 'spa unlock' from the keyring, along with the
+session key it is encrypted with. Your secret is asked for again afterwards.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := lock()
+		exit(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lockCmd)
+}
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/base32"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal"
+	"github.com/gostones/spa/internal/log"
+	"github.com/gostones/spa/internal/sec"
+)
+
+const (
+	defaultOTPAlgorithm = "SHA1"
+	defaultOTPDigits    = 6
+	defaultOTPPeriod    = 30
+)
+
+const (
+	saveOTPOverwrite = `
+Warning: you are about to replace the TOTP seed for %q
+
+The old seed will be lost.
+
+`
+	saveOTPPrompt = `Continue? [y/N] `
+	saveOTPDone   = `TOTP seed for %q has been encrypted and saved.
+`
+)
+
+// decodeOTPSecret decodes the base32 secret, case and padding insensitive.
+func decodeOTPSecret(s string) ([]byte, error) {
+	s = strings.ToUpper(normalize(s))
+	s = strings.TrimRight(s, "=")
+	b, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret: %v", err)
+	}
+	if len(b) == 0 {
+		return nil, fmt.Errorf("secret is required")
+	}
+	return b, nil
+}
+
+// parseOTPAuth parses the key URI:
+// otpauth://totp/Issuer:account?secret=BASE32&issuer=Issuer&algorithm=SHA1&digits=6&period=30
+func parseOTPAuth(uri string) (*internal.TOTPConfig, error) {
+	u, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "otpauth" {
+		return nil, fmt.Errorf("not an otpauth URI: %q", u.Scheme)
+	}
+	if u.Host != "totp" {
+		return nil, fmt.Errorf("unsupported type: %q. only totp is supported", u.Host)
+	}
+
+	q := u.Query()
+	c := &internal.TOTPConfig{
+		Secret:    strings.ToUpper(normalize(q.Get("secret"))),
+		Algorithm: defaultOTPAlgorithm,
+		Digits:    defaultOTPDigits,
+		Period:    defaultOTPPeriod,
+		Issuer:    q.Get("issuer"),
+	}
+	if _, err := decodeOTPSecret(c.Secret); err != nil {
+		return nil, err
+	}
+
+	label := strings.TrimPrefix(u.Path, "/")
+	if i := strings.Index(label, ":"); i >= 0 {
+		if c.Issuer == "" {
+			c.Issuer = strings.TrimSpace(label[:i])
+		}
+		label = label[i+1:]
+	}
+	c.Account = strings.TrimSpace(label)
+
+	if v := q.Get("algorithm"); v != "" {
+		c.Algorithm = strings.ToUpper(v)
+	}
+	if v := q.Get("digits"); v != "" {
+		if c.Digits, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("invalid digits: %q", v)
+		}
+	}
+	if v := q.Get("period"); v != "" {
+		if c.Period, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("invalid period: %q", v)
+		}
+	}
+
+	// validate the parameters
+	if _, err := totpCode(c, time.Now()); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func totpCode(c *internal.TOTPConfig, t time.Time) (string, error) {
+	key, err := decodeOTPSecret(c.Secret)
+	if err != nil {
+		return "", err
+	}
+	return sec.TOTP(key, t, c.Period, c.Digits, c.Algorithm)
+}
+
+// readOTPAuth reads the URI from the standard input if "-" is given.
+func readOTPAuth(v string) (string, error) {
+	if v != "-" {
+		return v, nil
+	}
+	s, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && s == "" {
+		return "", err
+	}
+	return strings.TrimSpace(s), nil
+}
+
+func importOTP() error {
+	uri, err := readOTPAuth(cfg.OTP.URI)
+	if err != nil {
+		return err
+	}
+	t, err := parseOTPAuth(uri)
+	if err != nil {
+		return err
+	}
+
+	peppers, err := readPepper()
+	if err != nil {
+		return err
+	}
+	du := domainUser(cfg.Domain, cfg.User)
+	c := peppers[du]
+	if c.TOTP != nil {
+		log.Infof(saveOTPOverwrite, du)
+		choice, err := log.Confirm(saveOTPPrompt)
+		if err != nil {
+			return err
+		}
+		if choice == "n" {
+			return nil
+		}
+	}
+	c.TOTP = t
+	peppers[du] = c
+
+	if err := writePepper(peppers); err != nil {
+		return err
+	}
+	log.Infof(saveOTPDone, du)
+	return nil
+}
+
+func showOTP() error {
+	s, err := decryptSafe(cfg.Secret.Foil)
+	if err != nil {
+		return err
+	}
+	cfg.Domain, cfg.User = s.resolve(cfg.Domain, cfg.User)
+	du := domainUser(cfg.Domain, cfg.User)
+	c, ok := s.Data[du]
+	if !ok || c.TOTP == nil {
+		return fmt.Errorf("no TOTP seed found for %q. please import one with --import", du)
+	}
+
+	now := time.Now()
+	code, err := totpCode(c.TOTP, now)
+	if err != nil {
+		return err
+	}
+	left := c.TOTP.Period - int(now.Unix()%int64(c.TOTP.Period))
+	log.Infof("%s (%vs left)\n", code, left)
+	return nil
+}
+
+func validateOTPFlags(cmd *cobra.Command, args []string) error {
+	if cfg.Domain == "" {
+		return fmt.Errorf("domain name is required")
+	}
+	return nil
+}
+
+// otpCmd represents the otp command
+var otpCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Use:                   "otp -d <DOMAIN NAME> [--import <URI>]",
+	Short:                 "Show TOTP code",
+	Long: `
+Show the current time-based one-time password (RFC 6238) for the site.
+
+Import the seed first from the otpauth:// URI provided by the web site when
+setting up two-factor authentication, usually shown as a QR code:
+
+spa otp -d example.com --import -
+
+Enter '-' to read the URI from the standard input instead of the command line.
+The seed is encrypted and saved along with the pepper for the site.
+`,
+	Args: validateOTPFlags,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := checkSaltSecret(); err != nil {
+			exit(err)
+		}
+
+		if cfg.OTP.URI != "" {
+			err := importOTP()
+			exit(err)
+		}
+		err := showOTP()
+		exit(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(otpCmd)
+
+	otpCmd.Flags().StringVar(&cfg.Salt.Raw, "salt", "", "specify a salt to use, whitespaces are ignored. default: saved in ~/.spa/salt")
+	otpCmd.Flags().StringVarP(&cfg.Secret.Raw, "secret", "s", "", "your secret")
+
+	otpCmd.Flags().VarP(newDomainValue("", &cfg.Domain), "domain", "d", "domain name of the web site. case insensitive. e.g. example.com")
+	otpCmd.Flags().StringVarP(&cfg.User, "user", "u", "", "optional username, email, or ID for the web site.")
+	otpCmd.Flags().StringVar(&cfg.OTP.URI, "import", "", "otpauth:// URI to import, '-' to read from the standard input")
+
+	addNewSiteFlag(otpCmd)
+
+	otpCmd.MarkFlagRequired("domain")
+
+	otpCmd.Flags().MarkHidden("salt")
+	otpCmd.Flags().MarkHidden("secret")
+}
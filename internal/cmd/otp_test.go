@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gostones/spa/internal"
+)
+
+func TestParseOTPAuth(t *testing.T) {
+	tests := []struct {
+		uri      string
+		expected *internal.TOTPConfig
+	}{
+		{"otpauth://totp/Example:alice@example.com?secret=JBSWY3DPEHPK3PXP&issuer=Example",
+			&internal.TOTPConfig{Secret: "JBSWY3DPEHPK3PXP", Algorithm: "SHA1", Digits: 6, Period: 30, Issuer: "Example", Account: "alice@example.com"}},
+		{"otpauth://totp/ACME%20Co:john.doe@email.com?secret=hxdmvjecjjwsrb3hwizr6ifugfmxhjk5&algorithm=sha256&digits=8&period=60",
+			&internal.TOTPConfig{Secret: "HXDMVJECJJWSRB3HWIZR6IFUGFMXHJK5", Algorithm: "SHA256", Digits: 8, Period: 60, Issuer: "ACME Co", Account: "john.doe@email.com"}},
+		{"otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP",
+			&internal.TOTPConfig{Secret: "JBSWY3DPEHPK3PXP", Algorithm: "SHA1", Digits: 6, Period: 30, Account: "alice"}},
+		{"https://totp/alice?secret=JBSWY3DPEHPK3PXP", nil},
+		{"otpauth://hotp/alice?secret=JBSWY3DPEHPK3PXP&counter=1", nil},
+		{"otpauth://totp/alice", nil},
+		{"otpauth://totp/alice?secret=not-base32!", nil},
+		{"otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&algorithm=MD5", nil},
+		{"otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&digits=x", nil},
+		{"otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&period=0", nil},
+	}
+	for i, tc := range tests {
+		got, err := parseOTPAuth(tc.uri)
+		if tc.expected == nil {
+			if err == nil {
+				t.Fatalf("[%v] expected error: %+v", i, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("[%v] %v", i, err)
+		}
+		if *got != *tc.expected {
+			t.Fatalf("[%v] got: %+v want: %+v", i, got, tc.expected)
+		}
+	}
+}
+
+func TestTOTPCode(t *testing.T) {
+	// base32 of "12345678901234567890"
+	c := &internal.TOTPConfig{Secret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", Algorithm: "SHA1", Digits: 8, Period: 30}
+	got, err := totpCode(c, time.Unix(59, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "94287082" {
+		t.Fatalf("got: %s want: 94287082", got)
+	}
+}
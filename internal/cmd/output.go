@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"github.com/gostones/spa/internal"
+	"github.com/gostones/spa/internal/format"
+	"github.com/gostones/spa/internal/log"
+)
+
+const (
+	outputPlain = "plain"
+	outputJSON  = "json"
+	outputTab   = "tab"
+)
+
+func validateOutput(output string) error {
+	switch output {
+	case outputPlain, outputJSON, outputTab:
+		return nil
+	}
+	return internal.NewUsageErrorf("invalid output: %q. valid values: %s, %s, %s", output, outputPlain, outputJSON, outputTab)
+}
+
+// printResult prints data to the standard output in json or tabular form, or
+// calls plain for the plain text output.
+func printResult(data interface{}, plain func()) {
+	if cfg.Output == outputPlain {
+		plain()
+		return
+	}
+	format.Print(cfg.Output, data)
+}
+
+// candidates picks the candidate at the PIN, or all if no PIN is provided.
+func candidates(values []string, pin int) []internal.Candidate {
+	var list []internal.Candidate
+	for i, v := range values {
+		if pin < 0 || pin == i {
+			list = append(list, internal.Candidate{PIN: i, Value: v})
+		}
+	}
+	return list
+}
+
+// printCandidates prints the candidates one per line.
+func printCandidates(list []internal.Candidate) {
+	for _, c := range list {
+		log.Infof("[%04v] %s\n", c.PIN, c.Value)
+	}
+}
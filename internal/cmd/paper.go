@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gostones/spa/internal/sec"
+)
+
+// Paper backup sheet
+//
+// SPA paper backup
+// created: 2021-08-01
+//
+// [key] 96 bytes
+// 001 able acid acorn actor agent alarm album alley - zebra
+// ...
+// [pepper] 1234 bytes
+// ...
+const paperTitle = "SPA paper backup"
+
+// paperSection is a named section of the sheet.
+type paperSection struct {
+	Name string
+	Data []byte
+}
+
+func writePaper(w io.Writer, sections []paperSection) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\ncreated: %s\n", paperTitle, time.Now().Format("2006-01-02"))
+	for _, s := range sections {
+		fmt.Fprintf(&buf, "\n[%s] %v bytes\n", s.Name, len(s.Data))
+		for _, line := range sec.EncodePaper(s.Data) {
+			fmt.Fprintln(&buf, line)
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func readPaper(r io.Reader) ([]paperSection, error) {
+	var sections []paperSection
+
+	var name string
+	var size int
+	var lines []string
+	flush := func() error {
+		if name == "" {
+			return nil
+		}
+		data, err := sec.DecodePaper(lines)
+		if err != nil {
+			return fmt.Errorf("[%s] %v", name, err)
+		}
+		if len(data) != size {
+			return fmt.Errorf("[%s] size mismatch: got %v bytes want %v, missing lines?", name, len(data), size)
+		}
+		sections = append(sections, paperSection{Name: name, Data: data})
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			idx := strings.Index(line, "]")
+			if idx < 0 {
+				return nil, fmt.Errorf("malformed section: %q", line)
+			}
+			name = line[1:idx]
+			fields := strings.Fields(line[idx+1:])
+			if len(fields) == 0 {
+				return nil, fmt.Errorf("[%s] size is missing", name)
+			}
+			n, err := strconv.Atoi(fields[0])
+			if err != nil {
+				return nil, fmt.Errorf("[%s] invalid size: %q", name, fields[0])
+			}
+			size = n
+			lines = nil
+			continue
+		}
+		// title and other text before the first section
+		if name == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return sections, nil
+}
+
+// compactSafe converts the pepper file content into the header followed by
+// the binary data, a quarter shorter than the base64 encoded file.
+func compactSafe(b []byte) ([]byte, error) {
+	h, dec, err := parseSafe(b)
+	if err != nil {
+		return nil, err
+	}
+	hb, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(hb, '\n'), dec...), nil
+}
+
+// expandSafe reverses compactSafe.
+func expandSafe(b []byte) ([]byte, error) {
+	idx := bytes.IndexByte(b, '\n')
+	if idx < 0 {
+		return nil, fmt.Errorf("pepper header is missing")
+	}
+	var buf bytes.Buffer
+	buf.Write(b[:idx+1])
+	buf.WriteString(base64.StdEncoding.EncodeToString(b[idx+1:]))
+	buf.WriteByte('\n')
+
+	if _, _, err := parseSafe(buf.Bytes()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
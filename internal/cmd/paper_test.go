@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPaperRoundTrip(t *testing.T) {
+	sections := []paperSection{
+		{Name: archiveKey, Data: []byte("0123456789abcdef0123456789abcdef")},
+		{Name: archivePepper, Data: []byte("{\"magic\":\"spa-safe\"}\nbinary")},
+	}
+
+	var buf bytes.Buffer
+	if err := writePaper(&buf, sections); err != nil {
+		t.Fatal(err)
+	}
+	t.Log(buf.String())
+
+	got, err := readPaper(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(sections) {
+		t.Fatalf("got: %v want: %v", len(got), len(sections))
+	}
+	for i, s := range sections {
+		if got[i].Name != s.Name || !bytes.Equal(got[i].Data, s.Data) {
+			t.Fatalf("[%v] got: %v want: %v", i, got[i], s)
+		}
+	}
+}
+
+func TestPaperMissingLine(t *testing.T) {
+	var buf bytes.Buffer
+	writePaper(&buf, []paperSection{{Name: archiveKey, Data: make([]byte, 96)}})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	truncated := strings.Join(lines[:len(lines)-1], "\n")
+	if _, err := readPaper(strings.NewReader(truncated)); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestCompactSafe(t *testing.T) {
+	b := []byte(`{"magic":"spa-safe","version":2,"cipher":"aes-256-gcm","kdf":"spa","keyLen":4,"iteration":6}` + "\nAAECAwQFBgc=\n")
+	c, err := compactSafe(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := expandSafe(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(e, b) {
+		t.Fatalf("got: %s want: %s", e, b)
+	}
+}
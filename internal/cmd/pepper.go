@@ -11,7 +11,10 @@ var pepperCmd = &cobra.Command{
 	Use:                   "pepper",
 	Short:                 "Show pepper",
 	Long: `
-Print the content of pepper to the standard output.
+Print the sites saved in the pepper file in a table: domain, user, password
+length, mask, note, and preset. Aliases are listed with the site they refer to.
+
+Use '--output json' for the full config of each site.
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := checkSaltSecret(); err != nil {
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal"
+	"github.com/gostones/spa/internal/log"
+	"github.com/gostones/spa/internal/sec"
+)
+
+const (
+	minPhraseWords     = 4
+	maxPhraseWords     = 24
+	defaultPhraseWords = 8
+
+	defaultPhraseSeparator = "-"
+)
+
+func genPhrase(cmd *cobra.Command) error {
+	peppers, err := readPepper()
+	if err != nil {
+		return err
+	}
+	du := domainUser(cfg.Domain, cfg.User)
+	c := peppers[du]
+
+	// default/old values if no new values are provided on command line.
+	p := internal.PhraseConfig{
+		Words:     defaultPhraseWords,
+		Separator: defaultPhraseSeparator,
+	}
+	if c.Phrase != nil {
+		p = *c.Phrase
+	}
+	if cmd.Flags().Changed("words") {
+		p.Words = cfg.Phrase.Words
+	}
+	if cmd.Flags().Changed("separator") {
+		p.Separator = cfg.Phrase.Separator
+	}
+	c.Phrase = &p
+
+	count := cfg.Count
+	if cfg.Pin >= 0 {
+		count = cfg.Pin + 1
+	}
+
+	phrases, err := phraseCandidates(cfg.Domain, cfg.User, c, count)
+	if err != nil {
+		return err
+	}
+
+	print := func(pin int) {
+		s := fmt.Sprintf("[%04v] %s", pin, phrases[pin])
+		log.Infoln(s)
+	}
+
+	if cfg.Pin < 0 {
+		for i := 0; i < len(phrases); i++ {
+			print(i)
+		}
+	} else {
+		print(cfg.Pin)
+	}
+
+	peppers[du] = c
+	return saveSite(peppers)
+}
+
+// phraseCandidates generates count passphrases for the site with the given config.
+func phraseCandidates(domain, user string, c internal.PwdConfig, count int) ([]string, error) {
+	domain, user = siteOrigin(domain, user, c.Origin)
+	g := sec.PhraseGen(cfg.Secret.Stock, cfg.Salt.Hash, hashKeyLen, sec.CurrentKDFProfile().KeyGenIteration)
+	return g(domain, user, c.Pepper, count, c.Phrase.Words, c.Phrase.Separator)
+}
+
+func validatePhraseFlags(cmd *cobra.Command, args []string) error {
+	if cfg.Domain == "" {
+		return fmt.Errorf("domain name is required")
+	}
+
+	if cfg.Count < 1 {
+		return internal.NewUsageErrorf("invalid count: %v", cfg.Count)
+	}
+
+	if cmd.Flags().Changed("words") && (cfg.Phrase.Words < minPhraseWords || cfg.Phrase.Words > maxPhraseWords) {
+		return fmt.Errorf("invalid number of words: %v. valid range [%v, %v]", cfg.Phrase.Words, minPhraseWords, maxPhraseWords)
+	}
+
+	return nil
+}
+
+// phraseCmd represents the phrase command
+var phraseCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Use:                   "phrase -d <DOMAIN NAME> [-p <PIN>] [--words <N>] [--separator <SEP>]",
+	Short:                 "Generate passphrases",
+	Long: `
+Generate a set of candidate passphrases made of common english words.
+
+Passphrases are easier to remember and type by hand than passwords, e.g. for
+disk encryption or a single sign-on master password. Each word carries 8 bits;
+use at least 8 words for a master password.
+
+The number of words and the separator are saved along with the pepper for the
+site; later runs need no flags.
+`,
+	Args: validatePhraseFlags,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := checkSaltSecret(); err != nil {
+			exit(err)
+		}
+
+		err := genPhrase(cmd)
+		exit(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(phraseCmd)
+
+	phraseCmd.Flags().StringVar(&cfg.Salt.Raw, "salt", "", "specify a salt to use, whitespaces are ignored. default: saved in ~/.spa/salt")
+	phraseCmd.Flags().StringVarP(&cfg.Secret.Raw, "secret", "s", "", "your secret")
+
+	phraseCmd.Flags().VarP(newDomainValue("", &cfg.Domain), "domain", "d", "domain name of the web site or a label, e.g. laptop-disk. case insensitive.")
+	phraseCmd.Flags().StringVarP(&cfg.User, "user", "u", "", "optional username, email, or ID for the web site.")
+
+	phraseCmd.Flags().IntVar(&cfg.Phrase.Words, "words", defaultPhraseWords, fmt.Sprintf("optional number of words, minimum %v maximum %v.", minPhraseWords, maxPhraseWords))
+	phraseCmd.Flags().StringVar(&cfg.Phrase.Separator, "separator", defaultPhraseSeparator, "optional separator between words.")
+
+	phraseCmd.Flags().IntVar(&cfg.Count, "count", defaultMaxPIN, "optional number of passphrases to generate")
+	phraseCmd.Flags().VarP(newPinValue(-1, &cfg.Pin), "pin", "p", "optional number to pick the passphrase, the full list will be shown if not provided.")
+
+	addNewSiteFlag(phraseCmd)
+
+	phraseCmd.MarkFlagRequired("domain")
+
+	phraseCmd.Flags().MarkHidden("salt")
+	phraseCmd.Flags().MarkHidden("secret")
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal"
+	"github.com/gostones/spa/internal/sec"
+)
+
+// toPolicy converts the saved site policy.
+func toPolicy(c *internal.PolicyConfig) sec.Policy {
+	return sec.Policy{
+		MinUpper:  c.MinUpper,
+		MinLower:  c.MinLower,
+		MinDigit:  c.MinDigit,
+		MinSymbol: c.MinSymbol,
+		MaxRepeat: c.MaxRepeat,
+		Symbols:   c.Symbols,
+	}
+}
+
+// mergePolicy overrides the saved site policy with the policy flags provided
+// on the command line. nil is returned if no rule is set.
+func mergePolicy(cmd *cobra.Command, old *internal.PolicyConfig) *internal.PolicyConfig {
+	var p internal.PolicyConfig
+	if old != nil {
+		p = *old
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("min-upper") {
+		p.MinUpper = cfg.Policy.MinUpper
+	}
+	if flags.Changed("min-lower") {
+		p.MinLower = cfg.Policy.MinLower
+	}
+	if flags.Changed("min-digit") {
+		p.MinDigit = cfg.Policy.MinDigit
+	}
+	if flags.Changed("min-symbol") {
+		p.MinSymbol = cfg.Policy.MinSymbol
+	}
+	if flags.Changed("max-repeat") {
+		p.MaxRepeat = cfg.Policy.MaxRepeat
+	}
+	if flags.Changed("symbols") {
+		p.Symbols = cfg.Policy.Symbols
+	}
+
+	if p == (internal.PolicyConfig{}) {
+		return nil
+	}
+	return &p
+}
+
+func validatePolicyFlags() error {
+	for _, v := range []int{cfg.Policy.MinUpper, cfg.Policy.MinLower, cfg.Policy.MinDigit, cfg.Policy.MinSymbol, cfg.Policy.MaxRepeat} {
+		if v < 0 {
+			return fmt.Errorf("invalid policy value: %v. must not be negative", v)
+		}
+	}
+	return nil
+}
+
+func addPolicyFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&cfg.Policy.MinUpper, "min-upper", 0, "optional minimum number of uppercase letters required by the web site")
+	cmd.Flags().IntVar(&cfg.Policy.MinLower, "min-lower", 0, "optional minimum number of lowercase letters required by the web site")
+	cmd.Flags().IntVar(&cfg.Policy.MinDigit, "min-digit", 0, "optional minimum number of digits required by the web site")
+	cmd.Flags().IntVar(&cfg.Policy.MinSymbol, "min-symbol", 0, "optional minimum number of symbols required by the web site")
+	cmd.Flags().IntVar(&cfg.Policy.MaxRepeat, "max-repeat", 0, "optional maximum number of identical consecutive characters allowed by the web site. 0 for no limit")
+	cmd.Flags().StringVar(&cfg.Policy.Symbols, "symbols", "", "optional set of symbols allowed by the web site. all symbols are allowed if not provided")
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal"
+	"github.com/gostones/spa/internal/log"
+	"github.com/gostones/spa/internal/sec"
+)
+
+// presetNone clears the saved preset.
+const presetNone = "none"
+
+// readPreset resolves the preset, length, mask, and policy of the site from the
+// saved config c and the flags provided on the command line.
+func readPreset(cmd *cobra.Command, c internal.PwdConfig) error {
+	flags := cmd.Flags()
+
+	base := c.Policy
+	if flags.Changed("preset") {
+		base = nil
+		switch cfg.Pwd.Preset {
+		case presetNone:
+			cfg.Pwd.Preset = ""
+			if !flags.Changed("length") {
+				cfg.Pwd.Length = defaultPwdLength
+			}
+			if !flags.Changed("mask") {
+				cfg.Pwd.Mask = sec.EncloseEscape
+			}
+		default:
+			p, _ := sec.LookupPreset(cfg.Pwd.Preset)
+			if !flags.Changed("length") {
+				cfg.Pwd.Length = p.Length
+			}
+			if !flags.Changed("mask") {
+				cfg.Pwd.Mask = p.Mask
+			}
+		}
+	} else {
+		cfg.Pwd.Preset = c.Preset
+	}
+
+	// rules provided on the command line override those of the preset
+	var preset *internal.PolicyConfig
+	if cfg.Pwd.Preset != "" {
+		p, ok := sec.LookupPreset(cfg.Pwd.Preset)
+		if !ok {
+			return fmt.Errorf("unknown preset: %q", cfg.Pwd.Preset)
+		}
+		preset = fromPolicy(p.Policy)
+		if base == nil {
+			base = preset
+		}
+	}
+	cfg.Pwd.Policy = mergePolicy(cmd, base)
+	if cfg.Pwd.Policy != nil && preset != nil && *cfg.Pwd.Policy == *preset {
+		cfg.Pwd.Policy = nil
+	}
+
+	return checkPwdLength(cfg.Pwd)
+}
+
+// checkPwdLength checks the length against the valid range of the preset.
+func checkPwdLength(c internal.PwdConfig) error {
+	min, max := minPwdLength, maxPwdLength
+	if c.Preset != "" {
+		p, ok := sec.LookupPreset(c.Preset)
+		if !ok {
+			return fmt.Errorf("unknown preset: %q", c.Preset)
+		}
+		min, max = p.MinLength, p.MaxLength
+	}
+	if c.Length < min || c.Length > max {
+		return fmt.Errorf("invalid length: %v. valid range [%v, %v]", c.Length, min, max)
+	}
+	return nil
+}
+
+func fromPolicy(p sec.Policy) *internal.PolicyConfig {
+	if p == (sec.Policy{}) {
+		return nil
+	}
+	return &internal.PolicyConfig{
+		MinUpper:  p.MinUpper,
+		MinLower:  p.MinLower,
+		MinDigit:  p.MinDigit,
+		MinSymbol: p.MinSymbol,
+		MaxRepeat: p.MaxRepeat,
+		Symbols:   p.Symbols,
+	}
+}
+
+func listPresets() error {
+	for _, p := range sec.Presets() {
+		log.Infof("%-12s length: %2v [%2v, %2v]  %s\n", p.Name, p.Length, p.MinLength, p.MaxLength, p.Description)
+	}
+	return nil
+}
+
+// presetCmd represents the preset command
+var presetCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Use:                   "preset",
+	Short:                 "List password presets",
+	Long: `
+List the built-in presets of password rules.
+
+Use a preset with: spa pwd -d <DOMAIN NAME> --preset <PRESET>
+
+The preset is saved along with the pepper for the site; later runs need no
+flags.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := listPresets()
+		exit(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(presetCmd)
+}
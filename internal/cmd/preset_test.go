@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal"
+	"github.com/gostones/spa/internal/sec"
+)
+
+func testPresetCmd(args map[string]string) *cobra.Command {
+	cfg.Pwd = internal.PwdConfig{}
+	cfg.Policy = internal.PolicyConfig{}
+
+	cmd := &cobra.Command{}
+	cmd.Flags().StringVar(&cfg.Pwd.Mask, "mask", sec.EncloseEscape, "")
+	cmd.Flags().IntVar(&cfg.Pwd.Length, "length", defaultPwdLength, "")
+	cmd.Flags().StringVar(&cfg.Pwd.Preset, "preset", "", "")
+	addPolicyFlags(cmd)
+	for k, v := range args {
+		cmd.Flags().Set(k, v)
+	}
+	return cmd
+}
+
+func TestReadPreset(t *testing.T) {
+	saved := internal.PwdConfig{Length: 6, Preset: "numeric6"}
+
+	tests := []struct {
+		saved    internal.PwdConfig
+		args     map[string]string
+		expected internal.PwdConfig
+		ok       bool
+	}{
+		{internal.PwdConfig{Length: 32, Mask: sec.EncloseEscape}, map[string]string{"preset": "numeric6"}, internal.PwdConfig{Length: 6, Preset: "numeric6"}, true},
+		{saved, nil, internal.PwdConfig{Length: 6, Preset: "numeric6"}, true},
+		{saved, map[string]string{"length": "7"}, internal.PwdConfig{}, false},
+		{saved, map[string]string{"length": "6"}, internal.PwdConfig{Length: 6, Preset: "numeric6"}, true},
+		{saved, map[string]string{"preset": "none"}, internal.PwdConfig{Length: 32, Mask: sec.EncloseEscape}, true},
+		{saved, map[string]string{"max-repeat": "1"}, internal.PwdConfig{Length: 6, Preset: "numeric6", Policy: &internal.PolicyConfig{MaxRepeat: 1}}, true},
+		{saved, map[string]string{"preset": "bank16", "length": "12"}, internal.PwdConfig{Length: 12, Preset: "bank16"}, true},
+		{saved, map[string]string{"preset": "bank16", "min-digit": "2"}, internal.PwdConfig{Length: 16, Preset: "bank16", Policy: &internal.PolicyConfig{MinUpper: 1, MinLower: 1, MinDigit: 2, MinSymbol: 1, MaxRepeat: 2}}, true},
+	}
+	for i, tc := range tests {
+		cmd := testPresetCmd(tc.args)
+		if !cmd.Flags().Changed("length") {
+			cfg.Pwd.Length = tc.saved.Length
+		}
+		if !cmd.Flags().Changed("mask") {
+			cfg.Pwd.Mask = tc.saved.Mask
+		}
+
+		err := readPreset(cmd, tc.saved)
+		if (err == nil) != tc.ok {
+			t.Fatalf("[%v] got: %v want ok: %v", i, err, tc.ok)
+		}
+		if err != nil {
+			continue
+		}
+		got := cfg.Pwd
+		if got.Length != tc.expected.Length || got.Mask != tc.expected.Mask || got.Preset != tc.expected.Preset {
+			t.Fatalf("[%v] got: %+v want: %+v", i, got, tc.expected)
+		}
+		if (got.Policy == nil) != (tc.expected.Policy == nil) || (got.Policy != nil && *got.Policy != *tc.expected.Policy) {
+			t.Fatalf("[%v] got: %+v want: %+v", i, got.Policy, tc.expected.Policy)
+		}
+	}
+}
+
+func TestValidatePwdLength(t *testing.T) {
+	defer func(d string, n int) { cfg.Domain, cfg.Count = d, n }(cfg.Domain, cfg.Count)
+	cfg.Domain, cfg.Count = "example.com", 1
+
+	tests := []struct {
+		args map[string]string
+		ok   bool
+	}{
+		{map[string]string{"length": "6"}, true},
+		{map[string]string{"length": "100"}, true},
+		{map[string]string{"preset": "numeric6", "length": "6"}, true},
+		{map[string]string{"preset": "numeric6", "length": "7"}, false},
+		{map[string]string{"preset": "none", "length": "6"}, false},
+		{map[string]string{"preset": "none", "length": "32"}, true},
+	}
+	for i, tc := range tests {
+		// the length is checked against the saved preset by readPreset
+		err := validatePwdFlags(testPresetCmd(tc.args), nil)
+		if (err == nil) != tc.ok {
+			t.Fatalf("[%v] got: %v want ok: %v", i, err, tc.ok)
+		}
+	}
+}
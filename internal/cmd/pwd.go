@@ -11,51 +11,122 @@ import (
 )
 
 func genPwd(cmd *cobra.Command) error {
-	peppers, err := readSite(cmd)
-	if err != nil {
-		return err
+	var peppers map[string]internal.PwdConfig
+	if cfg.Site.Revision > 0 {
+		c, err := readRevision(cfg.Site.Revision)
+		if err != nil {
+			return err
+		}
+		cfg.Pwd = c
+	} else {
+		p, err := readSite(cmd)
+		if err != nil {
+			return err
+		}
+		peppers = p
 	}
 
-	c := cfg.Pwd
 	count := cfg.Count
 	if cfg.Pin >= 0 {
 		count = cfg.Pin + 1
 	}
 
-	codebook := sec.MakeCodebook(sec.AlphaNumericSymbol, cfg.Pwd.Mask)
-	g, err := generator(codebook)
+	pwds, err := pwdCandidates(cfg.Domain, cfg.User, cfg.Pwd, count)
 	if err != nil {
 		return err
 	}
 
-	pwds, err := g(cfg.Domain, cfg.User, c.Pepper, count)
-	if err != nil {
-		return err
+	result := &internal.PwdResult{
+		Domain:    cfg.Domain,
+		User:      cfg.User,
+		Revision:  cfg.Site.Revision,
+		Passwords: candidates(pwds, cfg.Pin),
+	}
+	// nothing to print if a single password is copied or revealed
+	if !cfg.Clip.Copy && !(cfg.View.Reveal && cfg.Pin >= 0) {
+		shown := result
+		if cfg.View.Masked || cfg.View.Reveal {
+			r := *result
+			r.Passwords = maskCandidates(result.Passwords)
+			shown = &r
+		}
+		printResult(shown, func() {
+			printCandidates(shown.Passwords)
+		})
 	}
 
-	n := c.Length
-	print := func(pin int) {
-		s := fmt.Sprintf("[%04v] %s", pin, pwds[pin][0:n])
-		log.Infoln(s)
+	// past revisions are read only
+	if peppers != nil {
+		if err := saveSite(peppers); err != nil {
+			return err
+		}
 	}
 
-	if cfg.Pin < 0 {
-		for i := 0; i < len(pwds); i++ {
-			print(i)
+	if cfg.Clip.Copy {
+		return copyCandidate(domainUser(cfg.Domain, cfg.User), result.Passwords[0], cfg.Clip.Clear)
+	}
+	if cfg.View.Reveal {
+		return revealCandidate(result.Passwords)
+	}
+	return nil
+}
+
+// pwdCandidates generates count passwords for the site with the given config.
+func pwdCandidates(domain, user string, c internal.PwdConfig, count int) ([]string, error) {
+	domain, user = siteOrigin(domain, user, c.Origin)
+	codeset := sec.AlphaNumericSymbol
+	var policy *sec.Policy
+	uniform := false
+	if c.Preset != "" {
+		p, ok := sec.LookupPreset(c.Preset)
+		if !ok {
+			return nil, fmt.Errorf("unknown preset: %q", c.Preset)
 		}
-	} else {
-		print(cfg.Pin)
+		codeset = p.Codeset
+		if p.Policy != (sec.Policy{}) {
+			policy = &p.Policy
+		}
+		uniform = p.Uniform
+	}
+	if c.Policy != nil {
+		p := toPolicy(c.Policy)
+		policy = &p
 	}
 
-	if err := saveSite(peppers); err != nil {
-		return err
+	if policy != nil || uniform {
+		var r sec.Policy
+		if policy != nil {
+			r = *policy
+		}
+		keyGen := sec.PolicyKeyGen
+		if uniform {
+			keyGen = sec.UniformPolicyKeyGen
+		}
+		codebook := sec.MakeCodebook(r.Restrict(codeset), c.Mask)
+		g := keyGen(r, codebook, cfg.Secret.Stock, cfg.Salt.Hash, hashKeyLen, sec.CurrentKDFProfile().KeyGenIteration)
+		return g(domain, user, c.Pepper, count, c.Length)
 	}
 
-	return nil
+	codebook := sec.MakeCodebook(codeset, c.Mask)
+	g, err := generator(codebook)
+	if err != nil {
+		return nil, err
+	}
+
+	pwds, err := g(domain, user, c.Pepper, count)
+	if err != nil {
+		return nil, err
+	}
+
+	n := c.Length
+	for i := range pwds {
+		pwds[i] = pwds[i][0:n]
+	}
+	return pwds, nil
 }
 
 func readSite(cmd *cobra.Command) (map[string]internal.PwdConfig, error) {
-	peppers, err := readPepper(cfg.Domain, cfg.User)
+	peppers, err := readPepper()
 	if err != nil {
 		return nil, err
 	}
@@ -71,6 +142,16 @@ func readSite(cmd *cobra.Command) (map[string]internal.PwdConfig, error) {
 	if !cmd.Flags().Changed("length") {
 		cfg.Pwd.Length = c.Length
 	}
+	if !cmd.Flags().Changed("note") {
+		cfg.Pwd.Note = c.Note
+	}
+	// settings of other commands
+	cfg.Pwd.Phrase = c.Phrase
+	cfg.Pwd.TOTP = c.TOTP
+	cfg.Pwd.Origin = c.Origin
+	if err := readPreset(cmd, c); err != nil {
+		return nil, err
+	}
 
 	//
 	if cmd.Flags().Changed("pepper") {
@@ -113,17 +194,61 @@ func validatePwdFlags(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("domain name is required")
 	}
 
-	if cfg.Pwd.Length < minPwdLength || cfg.Pwd.Length > maxPwdLength {
-		return fmt.Errorf("invalid length: %v. valid range [%v, %v]", cfg.Pwd.Length, minPwdLength, maxPwdLength)
+	if cfg.Count < 1 {
+		return internal.NewUsageErrorf("invalid count: %v", cfg.Count)
+	}
+
+	if cmd.Flags().Changed("preset") && cfg.Pwd.Preset != presetNone {
+		if _, ok := sec.LookupPreset(cfg.Pwd.Preset); !ok {
+			return fmt.Errorf("unknown preset: %q. please run 'spa preset' for the list", cfg.Pwd.Preset)
+		}
+	}
+
+	// without --preset the length is checked against the saved preset of the
+	// site once it is read, see readPreset.
+	if cmd.Flags().Changed("length") && cmd.Flags().Changed("preset") {
+		c := cfg.Pwd
+		if c.Preset == presetNone {
+			c.Preset = ""
+		}
+		if err := checkPwdLength(c); err != nil {
+			return err
+		}
+	}
+
+	if err := validatePolicyFlags(); err != nil {
+		return err
+	}
+
+	if err := validateClipFlags(); err != nil {
+		return err
+	}
+
+	if err := validateViewFlags(); err != nil {
+		return err
+	}
+
+	if cmd.Flags().Changed("revision") {
+		if cfg.Site.Revision < 1 {
+			return fmt.Errorf("invalid revision: %v", cfg.Site.Revision)
+		}
+		for _, name := range pwdSettingFlags {
+			if cmd.Flags().Changed(name) {
+				return fmt.Errorf("--%s can not be used with --revision. past revisions are read only", name)
+			}
+		}
 	}
 
 	return nil
 }
 
+// flags that change the saved settings of the site
+var pwdSettingFlags = []string{"pepper", "note", "mask", "length", "preset", "new", "min-upper", "min-lower", "min-digit", "min-symbol", "max-repeat", "symbols"}
+
 // pwdCmd represents the pwd command
 var pwdCmd = &cobra.Command{
 	DisableFlagsInUseLine: true,
-	Use:                   "pwd -d <DOMAIN NAME> [-p <PIN>] [--pepper auto]",
+	Use:                   "pwd -d <DOMAIN NAME> [-p <PIN> [--copy]] [--pepper auto] [--new]",
 	Short:                 "Generate passwords",
 	Long: `
 Generate a set of candidate passwords to be used.
@@ -133,6 +258,23 @@ password index number (PIN).
 
 You can pick any password for use with your web site. Just remember to use
 the same PIN for the same site.
+
+Provide --new for a site the first time, or add it with 'spa site add'.
+
+Past settings are kept when they are replaced, e.g. by a new pepper. Provide
+--revision to generate the passwords of a past revision.
+
+If the web site has password rules, pick a preset with --preset or provide
+them with the --min-* flags, --max-repeat, and --symbols. Every generated
+password complies with the rules, which are saved along with the pepper for the
+site.
+
+Provide --copy with --pin to copy the password to the clipboard instead of
+printing it. The clipboard is cleared after --clear unless it has been replaced.
+
+Provide --masked to show the passwords partially masked, or --reveal to show a
+single password in the alternate screen of the terminal, cleared on keypress,
+so that it is not left in the scrollback.
 `,
 	Args: validatePwdFlags,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -158,10 +300,19 @@ func init() {
 
 	pwdCmd.Flags().StringVar(&cfg.Pwd.Mask, "mask", sec.EncloseEscape, "optional characters to exclude from generated password. you should only provide a mask for web sites that do not accept certain special symbols.")
 	pwdCmd.Flags().IntVar(&cfg.Pwd.Length, "length", defaultPwdLength, fmt.Sprintf("optional password length, minimum %v maximum %v. it is recommended you use the full length unless the web site sets a limit.", minPwdLength, maxPwdLength))
+	pwdCmd.Flags().StringVar(&cfg.Pwd.Preset, "preset", "", "optional preset of password rules for the web site, see 'spa preset' for the list. enter 'none' to clear the saved preset.")
+
+	addPolicyFlags(pwdCmd)
 
 	pwdCmd.Flags().IntVar(&cfg.Count, "count", defaultMaxPIN, "optional number of passwords to generate")
 	pwdCmd.Flags().VarP(newPinValue(-1, &cfg.Pin), "pin", "p", "optional number to pick the password, the full list will be shown if not provided.")
 
+	addClipFlags(pwdCmd)
+	addViewFlags(pwdCmd)
+
+	addNewSiteFlag(pwdCmd)
+	pwdCmd.Flags().IntVar(&cfg.Site.Revision, "revision", 0, "optional past revision of the site to generate passwords, see 'spa site history'.")
+
 	pwdCmd.MarkFlagRequired("domain")
 
 	pwdCmd.Flags().MarkHidden("salt")
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gostones/spa/internal"
+	"github.com/gostones/spa/internal/sec"
+)
+
+func TestReadSiteKeepsSettings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer os.Setenv(spaPepperFileEnv, os.Getenv(spaPepperFileEnv))
+	os.Setenv(spaPepperFileEnv, filepath.Join(dir, "pepper"))
+
+	defer useKDFProfile(sec.LegacyKDFProfile)
+	if err := useKDFProfile("fast-ci"); err != nil {
+		t.Fatal(err)
+	}
+	defer func(b []byte) { cfg.Secret.Foil = b }(cfg.Secret.Foil)
+	cfg.Secret.Foil, _ = sec.RandomBytes(hashKeyLen * 4)
+	defer func(d, u string) { cfg.Domain, cfg.User = d, u }(cfg.Domain, cfg.User)
+	cfg.Domain, cfg.User = "example.com", ""
+
+	tests := []internal.PwdConfig{
+		{Pepper: "a", Length: 32, Phrase: &internal.PhraseConfig{Words: 8, Separator: "-"}},
+		{Pepper: "a", Length: 32, TOTP: &internal.TOTPConfig{Secret: "JBSWY3DPEHPK3PXP", Algorithm: "SHA1", Digits: 6, Period: 30}},
+	}
+	for i, saved := range tests {
+		s := &Safe{Data: map[string]internal.PwdConfig{"example.com:": saved}}
+		if err := encryptSafe(cfg.Secret.Foil, s); err != nil {
+			t.Fatal(err)
+		}
+
+		// spa pwd -d example.com
+		peppers, err := readSite(testPresetCmd(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := saveSite(peppers); err != nil {
+			t.Fatal(err)
+		}
+
+		s, err = decryptSafe(cfg.Secret.Foil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := s.Data["example.com:"]
+		if !reflect.DeepEqual(got.Phrase, saved.Phrase) {
+			t.Fatalf("[%v] got: %+v want: %+v", i, got.Phrase, saved.Phrase)
+		}
+		if !reflect.DeepEqual(got.TOTP, saved.TOTP) {
+			t.Fatalf("[%v] got: %+v want: %+v", i, got.TOTP, saved.TOTP)
+		}
+	}
+}
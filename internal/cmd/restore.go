@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal/log"
+)
+
+const (
+	passphrasePrompt = `Enter the passphrase of the backup: `
+
+	restoreVerified = `Backup created at %v verified:
+`
+	restoreNoPepper = `Pepper is not included in the backup, secret is not verified.
+`
+	restoreOverwrite = `
+The following files exist. Their content will be overwritten:
+
+%s
+`
+	restorePrompt = `Continue? [y/N] `
+	restoreDone   = `Files have been successfully restored.
+`
+)
+
+func restore() error {
+	b, err := ioutil.ReadFile(cfg.Backup.File)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Backup.Passphrase == "" {
+		raw, err := log.PromptSecret(passphrasePrompt)
+		if err != nil {
+			return err
+		}
+		cfg.Backup.Passphrase = raw
+	}
+
+	a, err := openArchive([]byte(cfg.Backup.Passphrase), b)
+	if err != nil {
+		return err
+	}
+	log.Infof(restoreVerified, a.Created.Local().Format(time.RFC1123))
+	for _, e := range a.Manifest {
+		log.Infof("  %-8s %8v bytes  sha512: %s...\n", e.Name, e.Size, e.SHA512[0:16])
+	}
+
+	if err := verifyArchiveSecret(a); err != nil {
+		return err
+	}
+
+	names := archiveFilenames()
+	var existing []string
+	for name := range a.Files {
+		file, ok := names[name]
+		if !ok {
+			return fmt.Errorf("unknown file in backup: %q", name)
+		}
+		if checkFile(file) {
+			existing = append(existing, file)
+		}
+	}
+	if len(existing) > 0 {
+		sort.Strings(existing)
+		var list string
+		for _, v := range existing {
+			list += fmt.Sprintf("  %s\n", v)
+		}
+		log.Infof(restoreOverwrite, list)
+		choice, err := log.Confirm(restorePrompt)
+		if err != nil {
+			return err
+		}
+		if choice == "n" {
+			return nil
+		}
+	}
+
+	for name, data := range a.Files {
+		if err := writeFile(names[name], data); err != nil {
+			return err
+		}
+	}
+
+	log.Infof(restoreDone)
+	return nil
+}
+
+// verifyArchiveSecret decrypts the pepper safe in the archive with the secret
+// and the key and salt from the same archive.
+func verifyArchiveSecret(a *archive) error {
+	for _, name := range []string{archiveKey, archiveSalt} {
+		if _, ok := a.Files[name]; !ok {
+			return fmt.Errorf("%s file is missing from the backup", name)
+		}
+	}
+	pepper, ok := a.Files[archivePepper]
+	if !ok {
+		log.Infof(restoreNoPepper)
+		return nil
+	}
+
+	key, err := decodeKey(a.Files[archiveKey])
+	if err != nil {
+		return err
+	}
+	salt, profile, err := decodeSalt(a.Files[archiveSalt])
+	if err != nil {
+		return err
+	}
+	if err := useKDFProfile(profile); err != nil {
+		return err
+	}
+
+	if cfg.Secret.Raw == "" {
+		raw, err := readSecret(secretPrompt)
+		if err != nil {
+			return err
+		}
+		cfg.Secret.Raw = raw
+	}
+	secrets, err := hashSecret([]byte(cfg.Secret.Raw), key, salt)
+	if err != nil {
+		return err
+	}
+	if _, _, err := openSafe(secrets[1], pepper); err != nil {
+		return fmt.Errorf("failed to verify pepper in backup: %v", err)
+	}
+	return nil
+}
+
+// restoreCmd represents the restore command
+var restoreCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Use:                   "restore -i <FILE>",
+	Short:                 "Restore key, salt, and pepper",
+	Long: `
+Restore your key, salt, and pepper files from a backup created by 'spa backup'.
+
+The archive is decrypted and checked against its manifest; the pepper is then
+decrypted with your secret before any existing file is overwritten.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := restore()
+		exit(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+
+	restoreCmd.Flags().StringVarP(&cfg.Backup.File, "input", "i", "", "backup file to restore")
+	restoreCmd.Flags().StringVar(&cfg.Backup.Passphrase, "passphrase", "", "passphrase of the backup")
+	restoreCmd.Flags().StringVarP(&cfg.Secret.Raw, "secret", "s", "", "your secret")
+
+	restoreCmd.MarkFlagRequired("input")
+
+	restoreCmd.Flags().MarkHidden("passphrase")
+	restoreCmd.Flags().MarkHidden("secret")
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal"
+	"github.com/gostones/spa/internal/log"
+)
+
+const (
+	revealPrompt = `Enter PIN to reveal: `
+	revealDone   = `Press any key to clear the screen...`
+)
+
+// maskValue hides all but the first and last characters.
+func maskValue(s string) string {
+	r := []rune(s)
+	keep := len(r) / 8
+	if keep < 1 {
+		keep = 1
+	}
+	if keep > 2 {
+		keep = 2
+	}
+	if len(r) <= 2*keep+1 {
+		return strings.Repeat("*", len(r))
+	}
+	return string(r[:keep]) + strings.Repeat("*", len(r)-2*keep) + string(r[len(r)-keep:])
+}
+
+func maskCandidates(list []internal.Candidate) []internal.Candidate {
+	masked := make([]internal.Candidate, len(list))
+	for i, c := range list {
+		masked[i] = internal.Candidate{PIN: c.PIN, Value: maskValue(c.Value)}
+	}
+	return masked
+}
+
+// revealCandidate prompts for the PIN if there are more than one, and shows
+// the candidate in the alternate screen until a key is pressed.
+func revealCandidate(list []internal.Candidate) error {
+	if len(list) == 0 {
+		return fmt.Errorf("nothing to reveal")
+	}
+	c := list[0]
+	if len(list) > 1 {
+		v, err := log.Ask(revealPrompt)
+		if err != nil {
+			return err
+		}
+		pin, err := strconv.Atoi(v)
+		if err != nil || pin < list[0].PIN || pin > list[len(list)-1].PIN {
+			return fmt.Errorf("invalid PIN: %q", v)
+		}
+		c = list[pin-list[0].PIN]
+	}
+	return log.Reveal(fmt.Sprintf("[%04v] %s", c.PIN, c.Value), revealDone)
+}
+
+func validateViewFlags() error {
+	if !cfg.View.Reveal {
+		return nil
+	}
+	if cfg.Clip.Copy {
+		return internal.NewUsageError("--reveal can not be used with --copy")
+	}
+	if cfg.Output != outputPlain {
+		return internal.NewUsageErrorf("--reveal can not be used with --output %s", cfg.Output)
+	}
+	return nil
+}
+
+func addViewFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&cfg.View.Masked, "masked", false, "show the passwords partially masked.")
+	cmd.Flags().BoolVar(&cfg.View.Reveal, "reveal", false, "show the password of the PIN in the alternate screen until a key is pressed. you are prompted for the PIN if not provided.")
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestMaskValue(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"", ""},
+		{"abc", "***"},
+		{"abcd", "a**d"},
+		{"abcdefg", "a*****g"},
+		{"abcdefghijklmnop", "ab************op"},
+		{"abcdefghijklmnopqrstuvwxyz", "ab**********************yz"},
+		{"日本語です", "日***す"},
+	}
+
+	for i, test := range tests {
+		got := maskValue(test.s)
+		if got != test.want {
+			t.Fatalf("[%v] got: %v want: %v", i, got, test.want)
+		}
+	}
+}
+
+func TestRevealCandidateEmpty(t *testing.T) {
+	if err := revealCandidate(nil); err == nil {
+		t.Fatal("expected error with no candidates")
+	}
+}
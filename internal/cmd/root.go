@@ -54,6 +54,14 @@ same hackers; they have cracked your secret.
 
 SPA can also be used to genearate fake answers to security quesitons required
 for password resetting by some websites.
+
+Your secret is prompted for on the terminal. Scripts and launchers without a
+terminal can provide it with --secret-fd, the askpass program in SPA_ASKPASS,
+or the pinentry program in SPA_PINENTRY, e.g. pinentry-gnome3. These do not
+leave it in the shell history or the process environment as SPA_SECRET does.
+
+Run 'spa unlock' to enter and hash your secret once for the following commands
+until 'spa lock' or a timeout.
 `,
 	//	Run: func(cmd *cobra.Command, args []string) { },
 }
@@ -71,6 +79,8 @@ func init() {
 	rootCmd.CompletionOptions.DisableNoDescFlag = true
 	rootCmd.CompletionOptions.DisableDescriptions = true
 
+	rootCmd.PersistentFlags().StringVar(&cfg.Output, "output", outputPlain, fmt.Sprintf("output format: %s, %s, or %s. json and tab are written to the standard output", outputPlain, outputJSON, outputTab))
+	rootCmd.PersistentFlags().IntVar(&cfg.Secret.FD, "secret-fd", -1, "read your secret from the file descriptor, e.g. 3 with 3<file or 0 for the standard input.")
 	rootCmd.PersistentFlags().StringVar(&cfg.BaseDir, "config", "", fmt.Sprintf("custom location for storing salt hash and encrypted pepper (default %s environment variable or $HOME/%s)", spaConfigEnv, defaultDir))
 }
 
@@ -84,7 +94,12 @@ func initConfig() {
 		return v
 	}
 
-	if cfg.Secret.Raw == "" {
+	if err := validateOutput(cfg.Output); err != nil {
+		exit(err)
+	}
+
+	// the file descriptor is read when the secret is needed
+	if cfg.Secret.Raw == "" && cfg.Secret.FD < 0 {
 		cfg.Secret.Raw = os.Getenv(spaSecretEnv)
 	}
 
@@ -116,6 +131,8 @@ func initConfig() {
 // 1 -- general failure - any standard golang error
 // 2 -- usage error
 func exit(err error) {
+	wipeSecrets()
+
 	if err == nil {
 		os.Exit(0)
 	}
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gostones/spa/internal"
+	"github.com/gostones/spa/internal/sec"
+)
+
+// Pepper safe file format
+//
+// version 0 (legacy): base64(cipher||nonce)
+// version 2: a single line json header followed by base64(cipher||nonce), the
+// header is authenticated as additional data
+//
+// The header records the parameters the safe was encrypted with so that the
+// constants may change without orphaning existing files. The header is read
+// before it is authenticated, the work factor is bounded so that a tampered
+// file can not stall the key derivation.
+const (
+	safeMagic   = "spa-safe"
+	safeVersion = 2
+
+	maxSafeIteration = 64
+
+	safeCipher = "aes-256-gcm"
+	safeKDF    = "spa"
+)
+
+const migrateSafeMessage = `Migrating pepper file %q from version %v to %v, a backup is saved as %q
+`
+
+type safeHeader struct {
+	Magic     string `json:"magic"`
+	Version   int    `json:"version"`
+	Cipher    string `json:"cipher"`
+	KDF       string `json:"kdf"`
+	KeyLen    int    `json:"keyLen"`
+	Iteration int    `json:"iteration"`
+
+	// KDF profile, empty for the legacy profile
+	Profile string `json:"profile,omitempty"`
+}
+
+// newSafeHeader returns the header for writing with the current parameters.
+func newSafeHeader() *safeHeader {
+	return &safeHeader{
+		Magic:     safeMagic,
+		Version:   safeVersion,
+		Cipher:    safeCipher,
+		KDF:       safeKDF,
+		KeyLen:    hashKeyLen,
+		Iteration: sec.CurrentKDFProfile().CryptIteration,
+		Profile:   kdfProfileTag(),
+	}
+}
+
+// legacySafeHeader describes the untagged files written before versioning.
+// the values must never change.
+func legacySafeHeader() *safeHeader {
+	return &safeHeader{
+		Magic:     safeMagic,
+		Version:   0,
+		Cipher:    safeCipher,
+		KDF:       safeKDF,
+		KeyLen:    64,
+		Iteration: 6,
+	}
+}
+
+func (r *safeHeader) validate() error {
+	if r.Magic != safeMagic {
+		return fmt.Errorf("not a pepper file: %q", r.Magic)
+	}
+	if r.Version > safeVersion {
+		return fmt.Errorf("unsupported version: %v. please upgrade spa", r.Version)
+	}
+	if r.Version != 0 && r.Version != safeVersion {
+		return fmt.Errorf("unsupported version: %v", r.Version)
+	}
+	if r.Cipher != safeCipher || r.KDF != safeKDF {
+		return fmt.Errorf("unsupported cipher: %q kdf: %q", r.Cipher, r.KDF)
+	}
+	if r.KeyLen <= 0 || r.Iteration <= 0 || r.Iteration > maxSafeIteration {
+		return fmt.Errorf("invalid key length: %v iteration: %v", r.KeyLen, r.Iteration)
+	}
+	return nil
+}
+
+// additionalData returns the header authenticated by the cipher, nil for the
+// legacy format. The header is marshaled again rather than taken from the file so
+// that only the known fields are bound.
+func (r *safeHeader) additionalData() ([]byte, error) {
+	if r.Version == 0 {
+		return nil, nil
+	}
+	return json.Marshal(r)
+}
+
+// parseSafe splits the file content into header and the encrypted body.
+func parseSafe(b []byte) (*safeHeader, []byte, error) {
+	b = bytes.TrimSpace(b)
+
+	h := legacySafeHeader()
+	body := b
+	if bytes.HasPrefix(b, []byte("{")) {
+		idx := bytes.IndexByte(b, '\n')
+		if idx < 0 {
+			return nil, nil, fmt.Errorf("missing pepper data")
+		}
+		h = &safeHeader{}
+		if err := json.Unmarshal(b[:idx], h); err != nil {
+			return nil, nil, fmt.Errorf("invalid header: %v", err)
+		}
+		// only the untagged format is unauthenticated
+		if h.Version == 0 {
+			return nil, nil, fmt.Errorf("invalid header version: %v", h.Version)
+		}
+		body = bytes.TrimSpace(b[idx+1:])
+	}
+	if err := h.validate(); err != nil {
+		return nil, nil, err
+	}
+
+	dec, err := base64.StdEncoding.DecodeString(string(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(dec) <= h.KeyLen {
+		return nil, nil, fmt.Errorf("pepper data is too short")
+	}
+	return h, dec, nil
+}
+
+// checkSafeKey checks the key length of the header, which is not
+// authenticated, against the key that pickKey slices.
+func checkSafeKey(key []byte, h *safeHeader) error {
+	if h.KeyLen >= len(key) {
+		return fmt.Errorf("invalid key length: %v for a key of %v bytes", h.KeyLen, len(key))
+	}
+	return nil
+}
+
+// sealSafe encrypts the safe and returns the file content.
+func sealSafe(key []byte, h *safeHeader, s *Safe) ([]byte, error) {
+	if err := checkSafeKey(key, h); err != nil {
+		return nil, err
+	}
+	nonce, err := sec.RandomBytes(h.KeyLen)
+	if err != nil {
+		return nil, err
+	}
+	s.Nonce = nonce
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	defer sec.Wipe(data)
+	hb, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	ad, err := h.additionalData()
+	if err != nil {
+		return nil, err
+	}
+	secret := pickKey(key, nonce, h.KeyLen)
+	cipher, err := sec.EncryptAD(secret, data, ad, h.Iteration)
+	if err != nil {
+		return nil, err
+	}
+	enc := base64.StdEncoding.EncodeToString(append(cipher, nonce...))
+
+	var buf bytes.Buffer
+	buf.Write(hb)
+	buf.WriteByte('\n')
+	buf.WriteString(enc)
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// openSafe decrypts the file content of any supported version.
+func openSafe(key []byte, b []byte) (*safeHeader, *Safe, error) {
+	h, dec, err := parseSafe(b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := checkKDFProfile("pepper file", h.Profile); err != nil {
+		return nil, nil, err
+	}
+	if err := checkSafeKey(key, h); err != nil {
+		return nil, nil, err
+	}
+
+	ad, err := h.additionalData()
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce := dec[len(dec)-h.KeyLen:]
+	cipher := dec[0 : len(dec)-h.KeyLen]
+	secret := pickKey(key, nonce, h.KeyLen)
+	data, err := sec.DecryptAD(secret, cipher, ad, h.Iteration)
+	if err != nil {
+		return nil, nil, fmt.Errorf("incorrect secret or corrupted file (version %v): %v", h.Version, err)
+	}
+	defer sec.Wipe(data)
+
+	var s Safe
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, nil, err
+	}
+	if s.Data == nil {
+		s.Data = make(map[string]internal.PwdConfig)
+	}
+	return h, &s, nil
+}
@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gostones/spa/internal"
+	"github.com/gostones/spa/internal/sec"
+)
+
+func testSafe() *Safe {
+	return &Safe{
+		Data: map[string]internal.PwdConfig{
+			"example.com:user": {Pepper: "pepper", Mask: sec.EncloseEscape, Length: 16},
+		},
+	}
+}
+
+func TestSafeRoundTrip(t *testing.T) {
+	key, _ := sec.RandomBytes(hashKeyLen * 4)
+	h := newSafeHeader()
+	h.Iteration = 1
+
+	b, err := sealSafe(key, h, testSafe())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(b, []byte(`{"magic":"spa-safe","version":2,`)) {
+		t.Fatalf("missing header: %s", b)
+	}
+
+	got, s, err := openSafe(key, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *h {
+		t.Fatalf("got: %+v want: %+v", got, h)
+	}
+	if s.Data["example.com:user"] != testSafe().Data["example.com:user"] {
+		t.Fatalf("got: %+v", s.Data)
+	}
+
+	other, _ := sec.RandomBytes(hashKeyLen * 4)
+	if _, _, err := openSafe(other, b); err == nil {
+		t.Fatal("expected error with wrong key")
+	}
+
+	// the header is authenticated
+	tampered := bytes.Replace(b, []byte(`"version":2`), []byte(`"version":1`), 1)
+	if _, _, err := openSafe(key, tampered); err == nil {
+		t.Fatal("expected error with tampered header")
+	}
+}
+
+func TestSafeVersion1(t *testing.T) {
+	key, _ := sec.RandomBytes(hashKeyLen * 4)
+	h := newSafeHeader()
+	h.Version = 1
+	h.Iteration = 1
+
+	// the unauthenticated header was never released
+	b, err := sealSafe(key, h, testSafe())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := openSafe(key, b); err == nil {
+		t.Fatal("expected error with version 1")
+	}
+}
+
+func TestSafeLegacy(t *testing.T) {
+	key, _ := sec.RandomBytes(hashKeyLen * 4)
+	h := legacySafeHeader()
+
+	// untagged format written before versioning
+	nonce, _ := sec.RandomBytes(h.KeyLen)
+	s := testSafe()
+	s.Nonce = nonce
+	data, _ := json.Marshal(s)
+	cipher, err := sec.Encrypt(pickKey(key, nonce, h.KeyLen), data, h.Iteration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := []byte(base64.StdEncoding.EncodeToString(append(cipher, nonce...)))
+
+	got, _, err := openSafe(key, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != 0 {
+		t.Fatalf("got: %v want: 0", got.Version)
+	}
+}
+
+func TestSafeMigrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "pepper")
+	defer os.Setenv(spaPepperFileEnv, os.Getenv(spaPepperFileEnv))
+	os.Setenv(spaPepperFileEnv, file)
+
+	key, _ := sec.RandomBytes(hashKeyLen * 4)
+	h := legacySafeHeader()
+	nonce, _ := sec.RandomBytes(h.KeyLen)
+	s := testSafe()
+	s.Nonce = nonce
+	data, _ := json.Marshal(s)
+	cipher, err := sec.Encrypt(pickKey(key, nonce, h.KeyLen), data, h.Iteration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := []byte(base64.StdEncoding.EncodeToString(append(cipher, nonce...)))
+	if err := ioutil.WriteFile(file, legacy, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// reading leaves the file alone
+	s, err = decryptSafe(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(file); !bytes.Equal(b, legacy) {
+		t.Fatalf("got: %s want: %s", b, legacy)
+	}
+	if checkFile(file + ".v0.bak") {
+		t.Fatal("unexpected backup")
+	}
+
+	// writing migrates and keeps a backup
+	if err := encryptSafe(key, s); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(file + ".v0.bak"); !bytes.Equal(b, legacy) {
+		t.Fatalf("got: %s want: %s", b, legacy)
+	}
+	b, _ := ioutil.ReadFile(file)
+	if got, _, err := parseSafe(b); err != nil || got.Version != safeVersion {
+		t.Fatalf("got: %+v %v want: version %v", got, err, safeVersion)
+	}
+}
+
+func TestParseSafe(t *testing.T) {
+	tests := []struct {
+		input string
+		ok    bool
+	}{
+		{"", false},
+		{"not base64", false},
+		{`{"magic":"spa-safe","version":2}`, false},
+		{`{"magic":"other","version":2,"cipher":"aes-256-gcm","kdf":"spa","keyLen":64,"iteration":6}` + "\nAAAA", false},
+		{`{"magic":"spa-safe","version":99,"cipher":"aes-256-gcm","kdf":"spa","keyLen":64,"iteration":6}` + "\nAAAA", false},
+		{`{"magic":"spa-safe","version":0,"cipher":"aes-256-gcm","kdf":"spa","keyLen":1,"iteration":1}` + "\nAAAA", false},
+		{`{"magic":"spa-safe","version":1,"cipher":"aes-256-gcm","kdf":"spa","keyLen":1,"iteration":1}` + "\nAAAA", false},
+		{`{"magic":"spa-safe","version":2,"cipher":"des","kdf":"spa","keyLen":64,"iteration":6}` + "\nAAAA", false},
+		{`{"magic":"spa-safe","version":2,"cipher":"aes-256-gcm","kdf":"spa","keyLen":1,"iteration":65}` + "\nAAAA", false},
+		{`{"magic":"spa-safe","version":2,"cipher":"aes-256-gcm","kdf":"spa","keyLen":1,"iteration":2147483647}` + "\nAAAA", false},
+		{`{"magic":"spa-safe","version":2,"cipher":"aes-256-gcm","kdf":"spa","keyLen":1,"iteration":64}` + "\nAAAA", true},
+		{`{"magic":"spa-safe","version":2,"cipher":"aes-256-gcm","kdf":"spa","keyLen":1,"iteration":1}` + "\nAAAA", true},
+	}
+	for i, tc := range tests {
+		_, _, err := parseSafe([]byte(tc.input))
+		if (err == nil) != tc.ok {
+			t.Fatalf("[%v] got: %v want ok: %v", i, err, tc.ok)
+		}
+	}
+}
+
+func TestSafeKDFProfile(t *testing.T) {
+	defer useKDFProfile(sec.LegacyKDFProfile)
+	if err := useKDFProfile("fast-ci"); err != nil {
+		t.Fatal(err)
+	}
+
+	key, _ := sec.RandomBytes(hashKeyLen * 4)
+	h := newSafeHeader()
+	if h.Profile != "fast-ci" || h.Iteration != 1 {
+		t.Fatalf("got: %+v", h)
+	}
+	b, err := sealSafe(key, h, testSafe())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := openSafe(key, b); err != nil {
+		t.Fatal(err)
+	}
+
+	// the salt of another profile
+	useKDFProfile(sec.LegacyKDFProfile)
+	if _, _, err := openSafe(key, b); err == nil {
+		t.Fatal("expected error with another profile")
+	}
+	if h := newSafeHeader(); h.Profile != "" {
+		t.Fatalf("got: %q want: legacy header", h.Profile)
+	}
+}
+
+func TestSafeKeyLen(t *testing.T) {
+	key, _ := sec.RandomBytes(hashKeyLen * 4)
+	body := base64.StdEncoding.EncodeToString(make([]byte, 70001))
+
+	tests := []struct {
+		keyLen int
+		ok     bool
+	}{
+		{hashKeyLen, true},
+		{len(key) - 1, true},
+		{len(key), false},
+		{70000, false},
+	}
+	for i, tc := range tests {
+		h := newSafeHeader()
+		h.KeyLen = tc.keyLen
+		h.Iteration = 1
+		hb, _ := json.Marshal(h)
+
+		// the body does not decrypt, only the key length is checked before
+		_, _, err := openSafe(key, []byte(string(hb)+"\n"+body))
+		if err == nil {
+			t.Fatalf("[%v] got: nil want: error", i)
+		}
+		invalid := strings.HasPrefix(err.Error(), "invalid key length")
+		if invalid == tc.ok {
+			t.Fatalf("[%v] got: %v want ok: %v", i, err, tc.ok)
+		}
+	}
+}
@@ -1,15 +1,22 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/gostones/spa/internal/log"
+	"github.com/gostones/spa/internal/sec"
 	"github.com/spf13/cobra"
 )
 
 const (
 	computeSaltMessage = `
-Computing hash to be saved, this could take a few minutes...
+Computing hash to be saved, this could take a few minutes. Run 'spa bench' for an estimate...
 `
 	saveSaltOverrite = `
 Salt file exists. Its Content will be overwritten.
@@ -20,9 +27,34 @@ Salt file exists. Its Content will be overwritten.
 `
 )
 
+var errSaltCancelled = errors.New("cancelled, the salt file is unchanged")
+
 func saveSalt() error {
+	if err := useKDFProfile(cfg.Salt.Profile); err != nil {
+		return err
+	}
 	log.Infof(computeSaltMessage)
-	salt, err := hashSalt(normalizedSalt())
+
+	// stop hashing on Ctrl-C, nothing has been written yet.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sig)
+	go func() {
+		select {
+		case <-sig:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	bar := log.NewProgressBar()
+	salt, err := hashSalt(ctx, normalizedSalt(), bar.Update)
+	bar.Done()
+	if errors.Is(err, context.Canceled) {
+		return errSaltCancelled
+	}
 	if err != nil {
 		return err
 	}
@@ -43,7 +75,7 @@ func saveSalt() error {
 		}
 	}
 
-	if err := saveSaltFile(p, salt); err != nil {
+	if err := saveSaltFile(p, salt, cfg.Salt.Profile); err != nil {
 		return err
 	}
 
@@ -67,6 +99,10 @@ func validateSaltSaveFlags(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("salt is too short: %v, minimum required number of characters after removing spaces: %v", size, minSaltLen)
 	}
 
+	if _, ok := sec.LookupKDFProfile(cfg.Salt.Profile); !ok {
+		return fmt.Errorf("unknown KDF profile: %q. valid profiles: %s", cfg.Salt.Profile, strings.Join(sec.KDFProfileNames(), ", "))
+	}
+
 	return nil
 }
 
@@ -88,6 +124,15 @@ in case you have to recreate the hash value.
 
 A mininum of %v characters is required. You may use your favorite quotes, text
 on a static web page, or a message in your email archive...
+
+The work factors of the key derivation are chosen with --kdf-profile and saved
+along with the hash. The default v1-legacy profile generates the same passwords
+as earlier versions; paranoid is slower and uses more memory, v2-parallel
+splits the same work into lanes that run at the same time on several CPUs,
+fast-ci is only meant for tests. Run 'spa bench' to estimate how long each takes.
+
+Hashing can be interrupted with Ctrl-C, the salt file is written only once it
+is complete.
 `, minSaltLen),
 	Args: validateSaltSaveFlags,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -101,5 +146,7 @@ func init() {
 
 	saltSaveCmd.Flags().StringVar(&cfg.Salt.Raw, "text", "", fmt.Sprintf("provide the text for salt, whitespaces are ignored. minimum length: %v. its hash is saved as 'salt' in $HOME/%s by default", minSaltLen, defaultDir))
 
+	addKDFProfileFlag(saltSaveCmd)
+
 	saltSaveCmd.MarkFlagRequired("text")
 }
@@ -6,7 +6,7 @@ import (
 	"github.com/gostones/spa/internal/sec"
 )
 
-func changeSecret() error {
+func changeSecret(old []byte) error {
 	if cfg.Secret.NewRaw == "" {
 		raw, err := enterNewPassword()
 		if err != nil {
@@ -23,7 +23,7 @@ func changeSecret() error {
 	if err != nil {
 		return err
 	}
-	newKey := sec.SPIKey([]byte(cfg.Secret.Raw), key, []byte(cfg.Secret.NewRaw))
+	newKey := sec.SPIKey(old, key, []byte(cfg.Secret.NewRaw))
 	return writeKey(newKey)
 }
 
@@ -40,12 +40,24 @@ A key file will be generated or updated. default: ~/.spa/key. You should back up
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		// verify old secret if key file exists
+		var old *sec.Buffer
 		if checkFile(keyFilename()) {
+			// kept for the new key, checkSaltSecret drops it once hashed
+			if cfg.Secret.Raw == "" {
+				raw, err := readSecret(secretPrompt)
+				if err != nil {
+					exit(err)
+				}
+				cfg.Secret.Raw = raw
+			}
+			old = sec.NewBufferFrom([]byte(cfg.Secret.Raw))
 			if err := requireSecret(); err != nil {
+				old.Destroy()
 				exit(err)
 			}
 		}
-		err := changeSecret()
+		err := changeSecret(old.Bytes())
+		old.Destroy()
 		exit(err)
 	},
 }
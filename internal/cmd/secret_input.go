@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/gostones/spa/internal/log"
+	"github.com/gostones/spa/internal/pinentry"
+)
+
+const pinentryDesc = `Enter your secret for spa, the secure password assistant.`
+
+// readSecret reads the secret from the first source available:
+// --secret-fd, SPA_ASKPASS, SPA_PINENTRY, or the terminal.
+func readSecret(prompt string) (string, error) {
+	if cfg.Secret.FD >= 0 {
+		return readSecretFD(cfg.Secret.FD)
+	}
+	if p := os.Getenv(spaAskPassEnv); p != "" {
+		return readAskPass(p, prompt)
+	}
+	if p := os.Getenv(spaPinentryEnv); p != "" {
+		return pinentry.Read(p, pinentry.Options{
+			Title:  "spa",
+			Desc:   pinentryDesc,
+			Prompt: strings.TrimSpace(prompt),
+		})
+	}
+	return log.PromptSecret(prompt)
+}
+
+// secretFD reads the lines of --secret-fd one at a time, e.g. the old secret
+// and then the new one for spa secret.
+var secretFD = map[int]*bufio.Reader{}
+
+// readSecretFD reads the next line of the file descriptor.
+func readSecretFD(fd int) (string, error) {
+	r, ok := secretFD[fd]
+	if !ok {
+		f := os.NewFile(uintptr(fd), fmt.Sprintf("fd%v", fd))
+		if f == nil {
+			return "", fmt.Errorf("invalid file descriptor: %v", fd)
+		}
+		r = bufio.NewReader(f)
+		secretFD[fd] = r
+	}
+	return readSecretLine(r)
+}
+
+// readAskPass runs the program with the prompt as the argument and reads the
+// secret from its output, like SSH_ASKPASS.
+func readAskPass(program, prompt string) (string, error) {
+	cmd := exec.Command(program, prompt)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+	s, err := readSecretLine(out)
+	if werr := cmd.Wait(); err == nil && werr != nil {
+		err = fmt.Errorf("%s: %v", program, werr)
+	}
+	return s, err
+}
+
+// readSecretLine reads up to the end of the first line, the line ending is
+// removed.
+func readSecretLine(r io.Reader) (string, error) {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	s, err := br.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimRight(s, "\r\n"), nil
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestReadSecretLine(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"", ""},
+		{"secret", "secret"},
+		{"secret\n", "secret"},
+		{"secret\r\nnext\n", "secret"},
+		{" my secret \n", " my secret "},
+	}
+
+	for i, test := range tests {
+		got, err := readSecretLine(strings.NewReader(test.s))
+		if err != nil {
+			t.Fatalf("[%v] err: %v", i, err)
+		}
+		if got != test.want {
+			t.Fatalf("[%v] got: %q want: %q", i, got, test.want)
+		}
+	}
+}
+
+func TestReadSecretFD(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.WriteString("secret\nnew secret\n")
+	w.Close()
+
+	// one line at a time
+	for _, want := range []string{"secret", "new secret"} {
+		got, err := readSecretFD(int(r.Fd()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("got: %q want: %q", got, want)
+		}
+	}
+}
+
+func TestReadAskPass(t *testing.T) {
+	// echo prints the prompt back
+	got, err := readAskPass("echo", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "secret" {
+		t.Fatalf("got: %q want: %q", got, "secret")
+	}
+
+	if _, err := readAskPass("false", "secret"); err == nil {
+		t.Fatalf("got: %v want: error", err)
+	}
+}
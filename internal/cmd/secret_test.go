@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gostones/spa/internal/sec"
+)
+
+func TestChangeSecret(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer os.Setenv(spaKeyFileEnv, os.Getenv(spaKeyFileEnv))
+	os.Setenv(spaKeyFileEnv, filepath.Join(dir, "key"))
+
+	key, err := readKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := []byte("secret123")
+
+	defer func(s string) { cfg.Secret.NewRaw = s }(cfg.Secret.NewRaw)
+	cfg.Secret.NewRaw = "newsecret456"
+	if err := changeSecret(old); err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := readKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the new secret and key hash to the same value as the old ones
+	want := sec.SPIHash(old, key)
+	got := sec.SPIHash([]byte(cfg.Secret.NewRaw), newKey)
+	if !bytes.Equal(got, want) {
+		t.Fatalf("got: %x want: %x", got, want)
+	}
+}
@@ -1,38 +1,423 @@
 package cmd
 
 import (
-	// "fmt"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal"
+	"github.com/gostones/spa/internal/log"
+	"github.com/gostones/spa/internal/sec"
+)
+
+const (
+	defaultServerIdle = 15 * time.Minute
+
+	serverTokenSize = 32
+	maxRequestSize  = 1 << 16
+	maxServerCount  = 100
+
+	serverStarted = `SPA server listening on %s
+Auth token saved in %q
+`
+	serverLocked = `Secret wiped from memory, SPA server stopped.
+`
 )
 
+var errServerLocked = fmt.Errorf("server is locked")
+
+// genRequest is the body of a pwd or sq request.
+type genRequest struct {
+	Domain   string `json:"domain"`
+	User     string `json:"user"`
+	Question string `json:"question,omitempty"`
+	Pin      int    `json:"pin"`
+	Count    int    `json:"count"`
+}
+
+type candidate struct {
+	Pin   int    `json:"pin"`
+	Value string `json:"value"`
+}
+
+type genResponse struct {
+	Domain     string      `json:"domain"`
+	User       string      `json:"user"`
+	Candidates []candidate `json:"candidates"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// server answers generation requests with the secret unlocked once.
+type server struct {
+	mu sync.Mutex
+
+	token  []byte
+	idle   time.Duration
+	timer  *time.Timer
+	locked bool
+
+	// cached safe and the modification time of the pepper file
+	safe    *Safe
+	modTime time.Time
+
+	done chan struct{}
+}
+
+func newServer(token []byte, idle time.Duration) *server {
+	r := &server{
+		token: token,
+		idle:  idle,
+		done:  make(chan struct{}),
+	}
+	r.timer = time.AfterFunc(idle, r.lock)
+	return r
+}
+
+// lock wipes the secret from memory. No more requests are served afterwards.
+func (r *server) lock() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.locked {
+		return
+	}
+	r.locked = true
+	r.timer.Stop()
+	r.safe = nil
+
+	wipe(cfg.Secret.Stock)
+	wipe(cfg.Secret.Foil)
+	wipeSecrets()
+	cfg.Secret.Stock = nil
+	cfg.Secret.Foil = nil
+	cfg.Salt.Hash = nil
+	cfg.Secret.Raw = ""
+
+	close(r.done)
+}
+
+func (r *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/pwd", r.handle(r.pwd))
+	mux.HandleFunc("/v1/sq", r.handle(r.sq))
+	mux.HandleFunc("/v1/lock", func(w http.ResponseWriter, req *http.Request) {
+		if !r.authorized(req) {
+			writeJSON(w, http.StatusUnauthorized, &errorResponse{Error: "unauthorized"})
+			return
+		}
+		r.lock()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
+
+// authorized checks the bearer token of the request.
+func (r *server) authorized(req *http.Request) bool {
+	const prefix = "Bearer "
+	h := req.Header.Get("Authorization")
+	if !strings.HasPrefix(h, prefix) {
+		return false
+	}
+	t := []byte(strings.TrimPrefix(h, prefix))
+	return subtle.ConstantTimeCompare(t, []byte(sec.Base64(r.token))) == 1
+}
+
+func (r *server) handle(gen func(*genRequest) (*genResponse, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, &errorResponse{Error: "method not allowed"})
+			return
+		}
+		if !r.authorized(req) {
+			writeJSON(w, http.StatusUnauthorized, &errorResponse{Error: "unauthorized"})
+			return
+		}
+
+		gr := genRequest{
+			Pin:   -1,
+			Count: defaultMaxPIN,
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxRequestSize)).Decode(&gr); err != nil {
+			writeJSON(w, http.StatusBadRequest, &errorResponse{Error: err.Error()})
+			return
+		}
+		gr.Domain = strings.ToLower(strings.TrimSpace(gr.Domain))
+		if gr.Domain == "" {
+			writeJSON(w, http.StatusBadRequest, &errorResponse{Error: "domain name is required"})
+			return
+		}
+		if gr.Count < 1 || gr.Count > maxServerCount {
+			writeJSON(w, http.StatusBadRequest, &errorResponse{Error: fmt.Sprintf("invalid count: %v. valid range [1, %v]", gr.Count, maxServerCount)})
+			return
+		}
+		if gr.Pin >= maxServerCount {
+			writeJSON(w, http.StatusBadRequest, &errorResponse{Error: fmt.Sprintf("invalid pin: %v", gr.Pin)})
+			return
+		}
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		if r.locked {
+			writeJSON(w, http.StatusServiceUnavailable, &errorResponse{Error: errServerLocked.Error()})
+			return
+		}
+		r.timer.Reset(r.idle)
+
+		resp, err := gen(&gr)
+		switch err.(type) {
+		case nil:
+			writeJSON(w, http.StatusOK, resp)
+		case *internal.UsageError:
+			writeJSON(w, http.StatusBadRequest, &errorResponse{Error: err.Error()})
+		default:
+			log.Errorln(err)
+			writeJSON(w, http.StatusInternalServerError, &errorResponse{Error: err.Error()})
+		}
+	}
+}
+
+func (r *server) pwd(gr *genRequest) (*genResponse, error) {
+	s, err := r.readSafe()
+	if err != nil {
+		return nil, err
+	}
+	domain, user := s.resolve(gr.Domain, gr.User)
+	c, ok := s.Data[domainUser(domain, user)]
+	if !ok {
+		return nil, internal.NewUsageErrorf("site not found: %q. please run 'spa pwd' for the site first", domainUser(gr.Domain, gr.User))
+	}
+
+	pwds, err := pwdCandidates(domain, user, c, pinCount(gr))
+	if err != nil {
+		return nil, err
+	}
+	return newGenResponse(gr, pwds), nil
+}
+
+func (r *server) sq(gr *genRequest) (*genResponse, error) {
+	if gr.Question == "" {
+		return nil, internal.NewUsageError("security question is required")
+	}
+
+	answers, err := sqCandidates(gr.Domain, gr.User, gr.Question, pinCount(gr))
+	if err != nil {
+		return nil, err
+	}
+	return newGenResponse(gr, answers), nil
+}
+
+// readSafe returns the cached safe, it is decrypted again only if the pepper
+// file has been modified since.
+func (r *server) readSafe() (*Safe, error) {
+	var modTime time.Time
+	if fi, err := os.Stat(pepperFilename()); err == nil {
+		modTime = fi.ModTime()
+	}
+	if r.safe != nil && modTime.Equal(r.modTime) {
+		return r.safe, nil
+	}
+
+	s, err := decryptSafe(cfg.Secret.Foil)
+	if err != nil {
+		return nil, err
+	}
+	r.safe = s
+	r.modTime = modTime
+	return s, nil
+}
+
+func pinCount(gr *genRequest) int {
+	if gr.Pin >= 0 {
+		return gr.Pin + 1
+	}
+	return gr.Count
+}
+
+func newGenResponse(gr *genRequest, values []string) *genResponse {
+	resp := &genResponse{
+		Domain: gr.Domain,
+		User:   gr.User,
+	}
+	for i, v := range values {
+		if gr.Pin >= 0 && i != gr.Pin {
+			continue
+		}
+		resp.Candidates = append(resp.Candidates, candidate{Pin: i, Value: v})
+	}
+	return resp
+}
+
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func serverTokenFilename() string {
+	return filepath.Join(cfg.BaseDir, "server.token")
+}
+
+// listen binds to localhost only, either a tcp port or a unix socket.
+func listen() (net.Listener, error) {
+	if cfg.Server.Port > 0 {
+		return net.Listen("tcp", fmt.Sprintf("127.0.0.1:%v", cfg.Server.Port))
+	}
+
+	p := cfg.Server.Socket
+	if p == "" {
+		p = filepath.Join(cfg.BaseDir, "spa.sock")
+	}
+	if checkFile(p) {
+		// stale socket from a previous run
+		if c, err := net.Dial("unix", p); err == nil {
+			c.Close()
+			return nil, fmt.Errorf("server is already running on %q", p)
+		}
+		if err := os.Remove(p); err != nil {
+			return nil, err
+		}
+	}
+	l, err := net.Listen("unix", p)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(p, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+func runServer() error {
+	if cfg.Server.Idle <= 0 {
+		return internal.NewUsageErrorf("invalid idle timeout: %v", cfg.Server.Idle)
+	}
+
+	if err := requireSecret(); err != nil {
+		return err
+	}
+
+	token, err := sec.RandomBytes(serverTokenSize)
+	if err != nil {
+		return err
+	}
+	tf := serverTokenFilename()
+	perm := os.FileMode(0600)
+	if err := ioutil.WriteFile(tf, []byte(sec.Base64(token)), perm); err != nil {
+		return err
+	}
+	defer os.Remove(tf)
+	if err := os.Chmod(tf, perm); err != nil {
+		return err
+	}
+
+	l, err := listen()
+	if err != nil {
+		return err
+	}
+
+	s := newServer(token, cfg.Server.Idle)
+	hs := &http.Server{
+		Handler:      s.routes(),
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 5 * time.Minute,
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sig)
+
+	go func() {
+		select {
+		case <-sig:
+			s.lock()
+		case <-s.done:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		hs.Shutdown(ctx)
+	}()
+
+	log.Infof(serverStarted, l.Addr(), tf)
+
+	if err := hs.Serve(l); err != http.ErrServerClosed {
+		s.lock()
+		return err
+	}
+
+	log.Infof(serverLocked)
+	return nil
+}
+
 // serverCmd represents the server command
 var serverCmd = &cobra.Command{
 	Hidden:                true,
 	DisableFlagsInUseLine: true,
-	Use:                   "server",
-	Short:                 "A brief description of your command",
-	Long: `A longer description that spans multiple lines and likely contains examples
-and usage of using your command. For example:
+	Use:                   "server [--port <PORT> | --socket <PATH>] [--idle <DURATION>]",
+	Short:                 "Run password generation service",
+	Long: fmt.Sprintf(`
+Run a local password generation service.
+
+The secret is entered and verified once; passwords and answers are then
+generated on request without running the costly key derivation again.
+
+The service listens on a unix socket ($HOME/%s/spa.sock by default) or on a
+localhost tcp port. Every request must carry the auth token saved in
+$HOME/%s/server.token:
+
+Authorization: Bearer <TOKEN>
+
+POST /v1/pwd  {"domain": "example.com", "user": "", "pin": 0}
+POST /v1/sq   {"domain": "example.com", "question": "...", "count": 10}
+POST /v1/lock
+
+Only sites already saved with 'spa pwd' are served.
+
+The token is generated each time the service starts and is removed when it
+stops; it is the same for every connection in between. It is readable only by
+your user, so any program running as your user can read the token and request
+passwords. Other users can connect to the tcp port but are rejected without
+the token; the unix socket is not accessible to them at all. Requests and
+responses are not encrypted, they never leave the host. Do not run the service
+on a host where you do not trust the programs of your own user.
 
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
-	// Run: func(cmd *cobra.Command, args []string) {
-	// 	fmt.Println("server called")
-	// },
+The secret is wiped from memory and the service stops after being idle for
+the specified duration.
+`, defaultDir, defaultDir),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := runServer()
+		exit(err)
+	},
 }
 
 func init() {
 	rootCmd.AddCommand(serverCmd)
 
-	// Here you will define your flags and configuration settings.
+	serverCmd.Flags().StringVar(&cfg.Salt.Raw, "salt", "", "specify a salt to use, whitespaces are ignored. default: saved in ~/.spa/salt")
+	serverCmd.Flags().StringVarP(&cfg.Secret.Raw, "secret", "s", "", "your secret")
 
-	// Cobra supports Persistent Flags which will work for this command
-	// and all subcommands, e.g.:
-	// serverCmd.PersistentFlags().String("foo", "", "A help for foo")
+	serverCmd.Flags().IntVar(&cfg.Server.Port, "port", 0, "optional localhost tcp port to listen on instead of the unix socket")
+	serverCmd.Flags().StringVar(&cfg.Server.Socket, "socket", "", "optional unix socket path. default: spa.sock in the config directory")
+	serverCmd.Flags().DurationVar(&cfg.Server.Idle, "idle", defaultServerIdle, "wipe the secret and stop after being idle for the duration")
 
-	// Cobra supports local flags which will only run when this command
-	// is called directly, e.g.:
-	// serverCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	serverCmd.Flags().MarkHidden("secret")
+	serverCmd.Flags().MarkHidden("salt")
 }
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gostones/spa/internal/sec"
+)
+
+func TestServerAuthorized(t *testing.T) {
+	token := []byte("0123456789abcdef")
+	s := newServer(token, time.Minute)
+	defer s.timer.Stop()
+
+	tests := []struct {
+		header   string
+		expected bool
+	}{
+		{"", false},
+		{"Bearer", false},
+		{"Bearer ", false},
+		{"Bearer " + sec.Base64([]byte("fedcba9876543210")), false},
+		{"Basic " + sec.Base64(token), false},
+		{"Bearer " + sec.Base64(token), true},
+	}
+	for i, tc := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/v1/pwd", nil)
+		if tc.header != "" {
+			req.Header.Set("Authorization", tc.header)
+		}
+		if got := s.authorized(req); got != tc.expected {
+			t.Fatalf("[%v] got: %v want: %v", i, got, tc.expected)
+		}
+	}
+}
+
+func TestServerLock(t *testing.T) {
+	stock := []byte("stock")
+	foil := keepSecret(sec.NewBufferFrom([]byte("foil")))
+	cfg.Secret.Stock = stock
+	cfg.Secret.Foil = foil
+
+	token := []byte("0123456789abcdef")
+	s := newServer(token, 10*time.Millisecond)
+
+	select {
+	case <-s.done:
+	case <-time.After(time.Second):
+		t.Fatal("server not locked after idle timeout")
+	}
+
+	if !bytes.Equal(stock, make([]byte, 5)) || cfg.Secret.Stock != nil || cfg.Secret.Foil != nil {
+		t.Fatalf("secret not wiped: %v %v %v", stock, cfg.Secret.Stock, cfg.Secret.Foil)
+	}
+
+	body := strings.NewReader(`{"domain": "example.com", "question": "q"}`)
+	req := httptest.NewRequest(http.MethodPost, "/v1/sq", body)
+	req.Header.Set("Authorization", "Bearer "+sec.Base64(token))
+	w := httptest.NewRecorder()
+	s.routes().ServeHTTP(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("got: %v want: %v", w.Code, http.StatusServiceUnavailable)
+	}
+}
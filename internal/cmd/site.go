@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal/log"
+)
+
+const sitePrompt = `Continue? [y/N] `
+
+// maximum number of close matches suggested for an unknown site
+const maxSiteMatches = 3
+
+func splitDomainUser(du string) (string, string) {
+	sa := strings.SplitN(du, ":", 2)
+	if len(sa) < 2 {
+		return sa[0], ""
+	}
+	return sa[0], sa[1]
+}
+
+// siteOrigin returns the domain and user the passwords are generated for.
+func siteOrigin(domain, user, origin string) (string, string) {
+	if origin == "" {
+		return domain, user
+	}
+	return splitDomainUser(origin)
+}
+
+// resolve returns the domain and user of the record if domain:user is an alias.
+func (s *Safe) resolve(domain, user string) (string, string) {
+	if target, ok := s.Aliases[domainUser(domain, user)]; ok {
+		return splitDomainUser(target)
+	}
+	return domain, user
+}
+
+// record checks if there is a record of passwords or codes for the site.
+func (s *Safe) record(du string) bool {
+	_, p := s.Data[du]
+	_, c := s.Codes[du]
+	return p || c
+}
+
+func (s *Safe) exists(du string) bool {
+	_, ok := s.Aliases[du]
+	return ok || s.record(du)
+}
+
+// move renames the record, passwords and codes are unchanged.
+func (s *Safe) move(from, to string) error {
+	if _, ok := s.Aliases[from]; ok {
+		return fmt.Errorf("%q is an alias. please remove it and add an alias again", from)
+	}
+	if !s.record(from) {
+		return fmt.Errorf("site not found: %q", from)
+	}
+	if from == to || s.exists(to) {
+		return fmt.Errorf("site exists: %q", to)
+	}
+
+	// the origin is cleared if the site is moved back.
+	origin := func(o string) string {
+		if o == "" {
+			o = from
+		}
+		if o == to {
+			o = ""
+		}
+		return o
+	}
+	if c, ok := s.Data[from]; ok {
+		c.Origin = origin(c.Origin)
+		s.Data[to] = c
+		delete(s.Data, from)
+	}
+	if c, ok := s.Codes[from]; ok {
+		c.Origin = origin(c.Origin)
+		s.Codes[to] = c
+		delete(s.Codes, from)
+	}
+	if h, ok := s.History[from]; ok {
+		for i := range h {
+			h[i].Config.Origin = origin(h[i].Config.Origin)
+		}
+		s.History[to] = h
+		delete(s.History, from)
+	}
+	for k, v := range s.Aliases {
+		if v == from {
+			s.Aliases[k] = to
+		}
+	}
+	return nil
+}
+
+// alias makes alias resolve to the record of target.
+func (s *Safe) alias(alias, target string) error {
+	if t, ok := s.Aliases[target]; ok {
+		target = t
+	}
+	if !s.record(target) {
+		return fmt.Errorf("site not found: %q", target)
+	}
+	if alias == target || s.record(alias) {
+		return fmt.Errorf("site exists: %q. please remove it first", alias)
+	}
+	if _, ok := s.Aliases[alias]; ok {
+		return fmt.Errorf("alias exists: %q. please remove it first", alias)
+	}
+
+	if s.Aliases == nil {
+		s.Aliases = make(map[string]string)
+	}
+	s.Aliases[alias] = target
+	return nil
+}
+
+// remove deletes the alias, or the record and all its aliases which are
+// returned.
+func (s *Safe) remove(du string) ([]string, error) {
+	if _, ok := s.Aliases[du]; ok {
+		delete(s.Aliases, du)
+		return nil, nil
+	}
+	if !s.record(du) {
+		return nil, fmt.Errorf("site not found: %q", du)
+	}
+
+	delete(s.Data, du)
+	delete(s.Codes, du)
+	delete(s.History, du)
+	var aliases []string
+	for k, v := range s.Aliases {
+		if v == du {
+			aliases = append(aliases, k)
+			delete(s.Aliases, k)
+		}
+	}
+	return aliases, nil
+}
+
+// siteNotFound returns the error for an unknown site with close matches of the
+// saved sites.
+func siteNotFound(s *Safe, du string) error {
+	var keys []string
+	for k := range s.Data {
+		keys = append(keys, k)
+	}
+	for k := range s.Codes {
+		if _, ok := s.Data[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	for k := range s.Aliases {
+		keys = append(keys, k)
+	}
+
+	msg := fmt.Sprintf("site not found: %q.", du)
+	if matches := closeMatches(du, keys, maxSiteMatches); len(matches) > 0 {
+		msg += fmt.Sprintf(" did you mean: %s?", strings.Join(matches, ", "))
+	}
+	domain, user := splitDomainUser(du)
+	add := "spa site add -d " + domain
+	if user != "" {
+		add += " -u " + user
+	}
+	msg += fmt.Sprintf(" please run '%s' or provide --new to add the site", add)
+	return errors.New(msg)
+}
+
+// confirmSite prompts for confirmation of the change.
+func confirmSite(format string, a ...interface{}) (bool, error) {
+	log.Infof(format, a...)
+	choice, err := log.Confirm(sitePrompt)
+	if err != nil {
+		return false, err
+	}
+	return choice == "y", nil
+}
+
+func validateSiteFlags(cmd *cobra.Command, args []string) error {
+	if cfg.Domain == "" {
+		return fmt.Errorf("domain name is required")
+	}
+	return nil
+}
+
+func validateSiteToFlags(cmd *cobra.Command, args []string) error {
+	if err := validateSiteFlags(cmd, args); err != nil {
+		return err
+	}
+	if cfg.Site.Domain == "" {
+		return fmt.Errorf("new domain name is required")
+	}
+	// same user if not provided
+	if !cmd.Flags().Changed("to-user") {
+		cfg.Site.User = cfg.User
+	}
+	return nil
+}
+
+func addSiteFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&cfg.Secret.Raw, "secret", "s", "", "your secret")
+
+	cmd.Flags().VarP(newDomainValue("", &cfg.Domain), "domain", "d", "domain name of the web site. case insensitive. e.g. example.com")
+	cmd.Flags().StringVarP(&cfg.User, "user", "u", "", "optional username, email, or ID for the web site.")
+
+	cmd.MarkFlagRequired("domain")
+
+	cmd.Flags().MarkHidden("secret")
+}
+
+func addSiteToFlags(cmd *cobra.Command) {
+	cmd.Flags().Var(newDomainValue("", &cfg.Site.Domain), "to", "new domain name. case insensitive.")
+	cmd.Flags().StringVar(&cfg.Site.User, "to-user", "", "optional new username. default: the same username")
+
+	cmd.MarkFlagRequired("to")
+}
+
+// addNewSiteFlag adds the flag to add the site if it is not found.
+func addNewSiteFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&cfg.Site.New, "new", false, "add the site if it is not found.")
+}
+
+// siteCmd represents the site command
+var siteCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Use:                   "site",
+	Short:                 "Manage sites",
+	Long: `
+Add, rename, alias, and remove the sites saved in the pepper file.
+
+Passwords are generated from the original domain name and username of a site;
+they stay the same after the site is renamed.
+`,
+}
+
+func init() {
+	rootCmd.AddCommand(siteCmd)
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal/log"
+)
+
+const siteAddDone = `%q has been added.
+`
+
+func addSite(cmd *cobra.Command) error {
+	s, err := decryptSafe(cfg.Secret.Foil)
+	if err != nil {
+		return err
+	}
+	du := domainUser(cfg.Domain, cfg.User)
+	if s.exists(du) {
+		return fmt.Errorf("site exists: %q", du)
+	}
+
+	c, err := newPepper()
+	if err != nil {
+		return err
+	}
+	if cmd.Flags().Changed("pepper") && cfg.Pwd.Pepper != "auto" {
+		c.Pepper = cfg.Pwd.Pepper
+	}
+	c.Note = cfg.Pwd.Note
+
+	s.Data[du] = c
+	if err := encryptSafe(cfg.Secret.Foil, s); err != nil {
+		return err
+	}
+	log.Infof(siteAddDone, du)
+	return nil
+}
+
+// siteAddCmd represents the add command
+var siteAddCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Use:                   "add -d <DOMAIN NAME> [-u <USER>] [--pepper <PEPPER>] [--note <NOTE>]",
+	Short:                 "Add site",
+	Long: `
+Add a new site with a random pepper unless one is provided.
+
+Passwords are only generated for sites that have been added, so that a
+mistyped domain name is not saved by accident. Alternatively, provide --new
+when you generate passwords for the site the first time:
+
+spa pwd -d example.com --new
+`,
+	Args: validateSiteFlags,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := checkSaltSecret(); err != nil {
+			exit(err)
+		}
+
+		err := addSite(cmd)
+		exit(err)
+	},
+}
+
+func init() {
+	siteCmd.AddCommand(siteAddCmd)
+
+	addSiteFlags(siteAddCmd)
+	siteAddCmd.Flags().StringVar(&cfg.Pwd.Pepper, "pepper", "", "optional text to generate a different password. default: randomly generated")
+	siteAddCmd.Flags().StringVar(&cfg.Pwd.Note, "note", "", "attach a note for information only, note does not alter password generation.")
+}
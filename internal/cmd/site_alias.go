@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal/log"
+)
+
+const (
+	siteAliasConfirm = `
+You are about to make %q an alias of %q
+
+Passwords for the alias will be those of the site.
+
+`
+	siteAliasDone = `%q is now an alias of %q.
+`
+)
+
+func aliasSite() error {
+	s, err := decryptSafe(cfg.Secret.Foil)
+	if err != nil {
+		return err
+	}
+	alias := domainUser(cfg.Domain, cfg.User)
+	target := domainUser(s.resolve(cfg.Site.Domain, cfg.Site.User))
+	if err := s.alias(alias, target); err != nil {
+		return err
+	}
+
+	ok, err := confirmSite(siteAliasConfirm, alias, target)
+	if err != nil || !ok {
+		return err
+	}
+	if err := encryptSafe(cfg.Secret.Foil, s); err != nil {
+		return err
+	}
+	log.Infof(siteAliasDone, alias, target)
+	return nil
+}
+
+// siteAliasCmd represents the alias command
+var siteAliasCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Use:                   "alias -d <ALIAS> [-u <USER>] --to <DOMAIN NAME> [--to-user <USER>]",
+	Short:                 "Add site alias",
+	Long: `
+Add an alias for the site, e.g. for domains sharing the same account:
+
+spa site alias -d example.co.uk --to example.com
+
+The alias resolves to the site for all commands. Remove the alias with:
+
+spa site rm -d example.co.uk
+`,
+	Args: validateSiteToFlags,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := checkSaltSecret(); err != nil {
+			exit(err)
+		}
+
+		err := aliasSite()
+		exit(err)
+	},
+}
+
+func init() {
+	siteCmd.AddCommand(siteAliasCmd)
+
+	addSiteFlags(siteAliasCmd)
+	addSiteToFlags(siteAliasCmd)
+}
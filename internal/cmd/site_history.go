@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal"
+	"github.com/gostones/spa/internal/log"
+)
+
+const historyTimeFormat = "2006-01-02 15:04:05"
+
+// generative returns the part of the config that alters generation.
+func generative(c internal.PwdConfig) internal.PwdConfig {
+	c.Note = ""
+	c.TOTP = nil
+	return c
+}
+
+// changed checks if the passwords or passphrases of the site differ.
+func changed(old, c internal.PwdConfig) bool {
+	// passphrases are the same until the format is first saved.
+	if old.Phrase == nil || c.Phrase == nil {
+		old.Phrase, c.Phrase = nil, nil
+	}
+	return !reflect.DeepEqual(generative(old), generative(c))
+}
+
+// keepHistory adds the current config of each site to the history if it is
+// replaced by a different one.
+func (s *Safe) keepHistory(peppers map[string]internal.PwdConfig, now time.Time) {
+	for du, old := range s.Data {
+		c, ok := peppers[du]
+		if !ok || !changed(old, c) {
+			continue
+		}
+		if s.History == nil {
+			s.History = make(map[string][]internal.PwdRevision)
+		}
+		s.History[du] = append(s.History[du], internal.PwdRevision{
+			Replaced: now.UTC(),
+			Config:   old,
+		})
+	}
+}
+
+// revision returns the past config n of the site, 1 being the oldest.
+func (s *Safe) revision(du string, n int) (internal.PwdConfig, error) {
+	h := s.History[du]
+	if n < 1 || n > len(h) {
+		return internal.PwdConfig{}, fmt.Errorf("revision not found: %v. %q has %v revision(s), see 'spa site history'", n, du, len(h))
+	}
+	return h[n-1].Config, nil
+}
+
+// readRevision reads the past config n of the site.
+func readRevision(n int) (internal.PwdConfig, error) {
+	s, err := decryptSafe(cfg.Secret.Foil)
+	if err != nil {
+		return internal.PwdConfig{}, err
+	}
+	cfg.Domain, cfg.User = s.resolve(cfg.Domain, cfg.User)
+	return s.revision(domainUser(cfg.Domain, cfg.User), n)
+}
+
+func showHistory() error {
+	s, err := decryptSafe(cfg.Secret.Foil)
+	if err != nil {
+		return err
+	}
+	cfg.Domain, cfg.User = s.resolve(cfg.Domain, cfg.User)
+	du := domainUser(cfg.Domain, cfg.User)
+	c, ok := s.Data[du]
+	if !ok {
+		return siteNotFound(s, du)
+	}
+
+	print := func(rev string, replaced string, c internal.PwdConfig) {
+		log.Infof("[%7s] %-19s  pepper: %q length: %v preset: %q note: %q\n", rev, replaced, c.Pepper, c.Length, c.Preset, c.Note)
+	}
+	for i, r := range s.History[du] {
+		print(fmt.Sprintf("%04v", i+1), r.Replaced.Local().Format(historyTimeFormat), r.Config)
+	}
+	print("current", "", c)
+	return nil
+}
+
+// siteHistoryCmd represents the history command
+var siteHistoryCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Use:                   "history -d <DOMAIN NAME> [-u <USER>]",
+	Short:                 "Show site history",
+	Long: `
+Show the past configs of the site, oldest first, with the time each one was
+replaced, e.g. by a new pepper or length.
+
+Generate the passwords of a past config with:
+
+spa pwd -d <DOMAIN NAME> --revision <N>
+`,
+	Args: validateSiteFlags,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := checkSaltSecret(); err != nil {
+			exit(err)
+		}
+
+		err := showHistory()
+		exit(err)
+	},
+}
+
+func init() {
+	siteCmd.AddCommand(siteHistoryCmd)
+
+	addSiteFlags(siteHistoryCmd)
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gostones/spa/internal"
+)
+
+func TestKeepHistory(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	current := internal.PwdConfig{Pepper: "a", Length: 32, Note: "note"}
+
+	tests := []struct {
+		c        internal.PwdConfig
+		expected int
+	}{
+		{current, 0},
+		{internal.PwdConfig{Pepper: "a", Length: 32, Note: "new note"}, 0},
+		{internal.PwdConfig{Pepper: "a", Length: 32, TOTP: &internal.TOTPConfig{Secret: "A"}}, 0},
+		{internal.PwdConfig{Pepper: "a", Length: 32, Phrase: &internal.PhraseConfig{Words: 8}}, 0},
+		{internal.PwdConfig{Pepper: "b", Length: 32}, 1},
+		{internal.PwdConfig{Pepper: "a", Length: 16}, 1},
+		{internal.PwdConfig{Pepper: "a", Length: 32, Preset: "bank16"}, 1},
+		{internal.PwdConfig{Pepper: "a", Length: 32, Policy: &internal.PolicyConfig{MinDigit: 1}}, 1},
+	}
+	for i, tc := range tests {
+		s := &Safe{Data: map[string]internal.PwdConfig{"example.com:": current}}
+		s.keepHistory(map[string]internal.PwdConfig{"example.com:": tc.c, "new.com:": tc.c}, now)
+
+		h := s.History["example.com:"]
+		if len(h) != tc.expected {
+			t.Fatalf("[%v] got: %v want: %v", i, len(h), tc.expected)
+		}
+		if len(h) > 0 && (h[0].Config.Pepper != current.Pepper || !h[0].Replaced.Equal(now)) {
+			t.Fatalf("[%v] got: %+v", i, h[0])
+		}
+		if _, ok := s.History["new.com:"]; ok {
+			t.Fatalf("[%v] history of a new site", i)
+		}
+	}
+}
+
+func TestRevision(t *testing.T) {
+	s := &Safe{Data: map[string]internal.PwdConfig{"example.com:": {Pepper: "c"}}}
+	s.keepHistory(map[string]internal.PwdConfig{"example.com:": {Pepper: "b"}}, time.Now())
+	s.Data["example.com:"] = internal.PwdConfig{Pepper: "b"}
+	s.keepHistory(map[string]internal.PwdConfig{"example.com:": {Pepper: "a"}}, time.Now())
+
+	tests := []struct {
+		n        int
+		expected string
+		ok       bool
+	}{
+		{1, "c", true},
+		{2, "b", true},
+		{0, "", false},
+		{3, "", false},
+	}
+	for i, tc := range tests {
+		c, err := s.revision("example.com:", tc.n)
+		if (err == nil) != tc.ok {
+			t.Fatalf("[%v] got: %v want ok: %v", i, err, tc.ok)
+		}
+		if c.Pepper != tc.expected {
+			t.Fatalf("[%v] got: %v want: %v", i, c.Pepper, tc.expected)
+		}
+	}
+
+	// history is moved along with the site and keeps generating the same
+	if err := s.move("example.com:", "new.com:"); err != nil {
+		t.Fatal(err)
+	}
+	c, err := s.revision("new.com:", 1)
+	if err != nil || c.Origin != "example.com:" {
+		t.Fatalf("got: %+v %v", c, err)
+	}
+	if _, err := s.remove("new.com:"); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.History) != 0 {
+		t.Fatalf("got: %v", s.History)
+	}
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal/log"
+)
+
+const (
+	siteMoveConfirm = `
+You are about to rename %q to %q
+
+Passwords and codes for the site stay the same.
+
+`
+	siteMoveDone = `%q has been renamed to %q.
+`
+)
+
+func moveSite() error {
+	s, err := decryptSafe(cfg.Secret.Foil)
+	if err != nil {
+		return err
+	}
+	from := domainUser(cfg.Domain, cfg.User)
+	to := domainUser(cfg.Site.Domain, cfg.Site.User)
+	if err := s.move(from, to); err != nil {
+		return err
+	}
+
+	ok, err := confirmSite(siteMoveConfirm, from, to)
+	if err != nil || !ok {
+		return err
+	}
+	if err := encryptSafe(cfg.Secret.Foil, s); err != nil {
+		return err
+	}
+	log.Infof(siteMoveDone, from, to)
+	return nil
+}
+
+// siteMoveCmd represents the mv command
+var siteMoveCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Use:                   "mv -d <DOMAIN NAME> [-u <USER>] --to <DOMAIN NAME> [--to-user <USER>]",
+	Short:                 "Rename site",
+	Long: `
+Rename the site, e.g. when a company moves from old.com to new.com:
+
+spa site mv -d old.com --to new.com
+
+The pepper and settings are moved along with any aliases of the site.
+`,
+	Args: validateSiteToFlags,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := checkSaltSecret(); err != nil {
+			exit(err)
+		}
+
+		err := moveSite()
+		exit(err)
+	},
+}
+
+func init() {
+	siteCmd.AddCommand(siteMoveCmd)
+
+	addSiteFlags(siteMoveCmd)
+	addSiteToFlags(siteMoveCmd)
+}
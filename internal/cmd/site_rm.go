@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal/log"
+)
+
+const (
+	siteRemoveAliasConfirm = `
+You are about to remove the alias %q
+
+`
+	siteRemoveConfirm = `
+Warning: you are about to remove %q
+
+The pepper and settings will be lost; passwords for the site cannot be
+generated again unless you have a backup.
+
+`
+	siteRemoveAliases = `
+The aliases of the site will be removed too: %s
+`
+	siteRemoveDone = `%q has been removed.
+`
+)
+
+func removeSite() error {
+	s, err := decryptSafe(cfg.Secret.Foil)
+	if err != nil {
+		return err
+	}
+	du := domainUser(cfg.Domain, cfg.User)
+	_, alias := s.Aliases[du]
+	aliases, err := s.remove(du)
+	if err != nil {
+		return err
+	}
+
+	var ok bool
+	if alias {
+		ok, err = confirmSite(siteRemoveAliasConfirm, du)
+	} else {
+		if len(aliases) > 0 {
+			sort.Strings(aliases)
+			log.Infof(siteRemoveAliases, strings.Join(aliases, ", "))
+		}
+		ok, err = confirmSite(siteRemoveConfirm, du)
+	}
+	if err != nil || !ok {
+		return err
+	}
+	if err := encryptSafe(cfg.Secret.Foil, s); err != nil {
+		return err
+	}
+	log.Infof(siteRemoveDone, du)
+	return nil
+}
+
+// siteRemoveCmd represents the rm command
+var siteRemoveCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Use:                   "rm -d <DOMAIN NAME> [-u <USER>]",
+	Short:                 "Remove site",
+	Long: `
+Remove the site or the alias.
+
+Removing a site removes its pepper, settings, and aliases.
+`,
+	Args: validateSiteFlags,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := checkSaltSecret(); err != nil {
+			exit(err)
+		}
+
+		err := removeSite()
+		exit(err)
+	},
+}
+
+func init() {
+	siteCmd.AddCommand(siteRemoveCmd)
+
+	addSiteFlags(siteRemoveCmd)
+}
@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gostones/spa/internal"
+)
+
+func testSites() *Safe {
+	return &Safe{
+		Data: map[string]internal.PwdConfig{
+			"old.com:":     {Pepper: "a"},
+			"example.com:": {Pepper: "b", Origin: "new.com:"},
+			"bank.com:bob": {Pepper: "c"},
+		},
+		Codes: map[string]internal.CodeConfig{
+			"old.com:":   {Pepper: "d"},
+			"phone.com:": {Pepper: "e"},
+		},
+		Aliases: map[string]string{
+			"old.co.uk:": "old.com:",
+		},
+	}
+}
+
+func TestSiteMove(t *testing.T) {
+	tests := []struct {
+		from, to string
+		ok       bool
+		origin   string
+	}{
+		{"old.com:", "new.com:", true, "old.com:"},
+		{"example.com:", "new.com:", true, ""},
+		{"example.com:", "other.com:", true, "new.com:"},
+		{"bank.com:bob", "bank.com:alice", true, "bank.com:bob"},
+		{"phone.com:", "mobile.com:", true, ""},
+		{"old.com:", "old.com:", false, ""},
+		{"old.com:", "bank.com:bob", false, ""},
+		{"old.com:", "old.co.uk:", false, ""},
+		{"old.co.uk:", "new.com:", false, ""},
+		{"missing.com:", "new.com:", false, ""},
+	}
+	for i, tc := range tests {
+		s := testSites()
+		err := s.move(tc.from, tc.to)
+		if (err == nil) != tc.ok {
+			t.Fatalf("[%v] got: %v want ok: %v", i, err, tc.ok)
+		}
+		if err != nil {
+			if !reflect.DeepEqual(s, testSites()) {
+				t.Fatalf("[%v] safe changed on error", i)
+			}
+			continue
+		}
+		if s.exists(tc.from) || !s.record(tc.to) {
+			t.Fatalf("[%v] not moved: %+v", i, s)
+		}
+		if c, ok := s.Data[tc.to]; ok && c.Origin != tc.origin {
+			t.Fatalf("[%v] got: %q want: %q", i, c.Origin, tc.origin)
+		}
+		for k, v := range s.Aliases {
+			if v == tc.from {
+				t.Fatalf("[%v] alias not moved: %q", i, k)
+			}
+		}
+	}
+
+	// codes are moved along with the passwords
+	s := testSites()
+	s.move("old.com:", "new.com:")
+	if c := s.Codes["new.com:"]; c.Pepper != "d" || c.Origin != "old.com:" {
+		t.Fatalf("got: %+v", c)
+	}
+	if got := s.Aliases["old.co.uk:"]; got != "new.com:" {
+		t.Fatalf("got: %q want: %q", got, "new.com:")
+	}
+}
+
+func TestSiteAlias(t *testing.T) {
+	tests := []struct {
+		alias, target string
+		ok            bool
+		expected      string
+	}{
+		{"example.org:", "old.com:", true, "old.com:"},
+		{"example.org:", "old.co.uk:", true, "old.com:"},
+		{"example.org:", "phone.com:", true, "phone.com:"},
+		{"old.com:", "bank.com:bob", false, ""},
+		{"old.co.uk:", "bank.com:bob", false, ""},
+		{"old.com:", "old.com:", false, ""},
+		{"example.org:", "missing.com:", false, ""},
+	}
+	for i, tc := range tests {
+		s := testSites()
+		err := s.alias(tc.alias, tc.target)
+		if (err == nil) != tc.ok {
+			t.Fatalf("[%v] got: %v want ok: %v", i, err, tc.ok)
+		}
+		if err != nil {
+			continue
+		}
+		domain, user := s.resolve(splitDomainUser(tc.alias))
+		if got := domainUser(domain, user); got != tc.expected {
+			t.Fatalf("[%v] got: %q want: %q", i, got, tc.expected)
+		}
+	}
+}
+
+func TestSiteRemove(t *testing.T) {
+	tests := []struct {
+		du      string
+		ok      bool
+		aliases []string
+	}{
+		{"old.com:", true, []string{"old.co.uk:"}},
+		{"old.co.uk:", true, nil},
+		{"bank.com:bob", true, nil},
+		{"phone.com:", true, nil},
+		{"missing.com:", false, nil},
+	}
+	for i, tc := range tests {
+		s := testSites()
+		aliases, err := s.remove(tc.du)
+		if (err == nil) != tc.ok {
+			t.Fatalf("[%v] got: %v want ok: %v", i, err, tc.ok)
+		}
+		if err != nil {
+			continue
+		}
+		if s.exists(tc.du) {
+			t.Fatalf("[%v] not removed", i)
+		}
+		if !reflect.DeepEqual(aliases, tc.aliases) {
+			t.Fatalf("[%v] got: %v want: %v", i, aliases, tc.aliases)
+		}
+		for _, a := range aliases {
+			if s.exists(a) {
+				t.Fatalf("[%v] alias not removed: %q", i, a)
+			}
+		}
+	}
+	// the record of an alias is kept
+	s := testSites()
+	s.remove("old.co.uk:")
+	if !s.record("old.com:") {
+		t.Fatal("record removed")
+	}
+}
+
+func TestSiteOrigin(t *testing.T) {
+	tests := []struct {
+		domain, user, origin string
+		domainE, userE       string
+	}{
+		{"new.com", "bob", "", "new.com", "bob"},
+		{"new.com", "bob", "old.com:alice", "old.com", "alice"},
+		{"new.com", "bob", "old.com:", "old.com", ""},
+		{"new.com", "", "old.com:a:b", "old.com", "a:b"},
+	}
+	for i, tc := range tests {
+		d, u := siteOrigin(tc.domain, tc.user, tc.origin)
+		if d != tc.domainE || u != tc.userE {
+			t.Fatalf("[%v] got: %q %q want: %q %q", i, d, u, tc.domainE, tc.userE)
+		}
+	}
+}
+
+func TestSiteNotFound(t *testing.T) {
+	tests := []struct {
+		du       string
+		expected string
+	}{
+		{"old.co:", "did you mean: old.com:?"},
+		{"bank.com:bbo", "did you mean: bank.com:bob?"},
+		{"phone.co:", "did you mean: phone.com:?"},
+		{"unknown.net:", "please run 'spa site add -d unknown.net'"},
+		{"unknown.net:bob", "please run 'spa site add -d unknown.net -u bob'"},
+	}
+	for i, tc := range tests {
+		err := siteNotFound(testSites(), tc.du)
+		if !strings.Contains(err.Error(), tc.expected) {
+			t.Fatalf("[%v] got: %v want: %v", i, err, tc.expected)
+		}
+	}
+}
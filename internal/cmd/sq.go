@@ -3,7 +3,7 @@ package cmd
 import (
 	"fmt"
 
-	"github.com/gostones/spa/internal/log"
+	"github.com/gostones/spa/internal"
 	"github.com/gostones/spa/internal/sec"
 	"github.com/spf13/cobra"
 )
@@ -21,42 +21,50 @@ func validateSecureQuestionFlags(cmd *cobra.Command, args []string) error {
 }
 
 func genSQ() error {
-	codebook := sec.MakeCodebook(sec.AlphaNumeric, "")
-	g, err := generator(codebook)
+	count := cfg.Count
+	if cfg.Pin >= 0 {
+		count = cfg.Pin + 1
+	}
+
+	answers, err := sqCandidates(cfg.Domain, cfg.User, cfg.Question.Question, count)
 	if err != nil {
 		return err
 	}
 
-	c := cfg.Question
+	result := &internal.SQResult{
+		Domain:   cfg.Domain,
+		User:     cfg.User,
+		Question: cfg.Question.Question,
+		Answers:  candidates(answers, cfg.Pin),
+	}
+	printResult(result, func() {
+		printCandidates(result.Answers)
+	})
 
-	count := cfg.Count
-	if cfg.Pin >= 0 {
-		count = cfg.Pin + 1
+	return nil
+}
+
+// sqCandidates generates count fake answers to the security question.
+func sqCandidates(domain, user, question string, count int) ([]string, error) {
+	codebook := sec.MakeCodebook(sec.AlphaNumeric, "")
+	g, err := generator(codebook)
+	if err != nil {
+		return nil, err
 	}
+
 	//
-	answers, err := g(cfg.Domain, cfg.User, normalize(c.Question), count)
+	answers, err := g(domain, user, normalize(question), count)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	min := 16
-	print := func(pin int) {
-		answer := answers[pin]
+	for i, answer := range answers {
 		// only part of the answer
 		n := min + int(sec.FNV([]byte(answer), uint32(min)))
-		s := fmt.Sprintf("[%04v] %s", pin, sec.SpaceOut(answer[0:n], 0))
-		log.Infoln(s)
-	}
-
-	if cfg.Pin < 0 {
-		for i := 0; i < len(answers); i++ {
-			print(i)
-		}
-	} else {
-		print(cfg.Pin)
+		answers[i] = sec.SpaceOut(answer[0:n], 0)
 	}
-
-	return nil
+	return answers, nil
 }
 
 // sqCmd represents the sq command
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal/log"
+	"github.com/gostones/spa/internal/sec"
+)
+
+const defaultSSHKeyFile = "id_ed25519"
+
+const (
+	sshKeyOverwrite = `
+Key file %q exists. Its content will be overwritten.
+
+`
+	sshKeyPrompt = `Continue? [y/N] `
+	sshKeyDone   = `SSH key pair has been saved in %q and %q
+%s
+`
+	sshKeyPassphrase = `The private key is protected with password [%04v] of %q
+`
+)
+
+func genSSHKey() error {
+	peppers, err := readPepper()
+	if err != nil {
+		return err
+	}
+	du := domainUser(cfg.Domain, cfg.User)
+	c := peppers[du]
+
+	pin := cfg.Pin
+	if pin < 0 {
+		pin = 0
+	}
+
+	g := sec.SSHKeyGen(cfg.Secret.Stock, cfg.Salt.Hash, hashKeyLen, sec.CurrentKDFProfile().KeyGenIteration)
+	domain, user := siteOrigin(cfg.Domain, cfg.User, c.Origin)
+	keys, err := g(domain, user, c.Pepper, pin+1)
+	if err != nil {
+		return err
+	}
+	key := keys[pin]
+
+	var passphrase []byte
+	if cfg.SSH.Passphrase {
+		pwds, err := pwdCandidates(cfg.Domain, cfg.User, c, pin+1)
+		if err != nil {
+			return err
+		}
+		passphrase = []byte(pwds[pin])
+	}
+
+	comment := cfg.Domain
+	if cfg.User != "" {
+		comment = cfg.User + "@" + cfg.Domain
+	}
+	priv, err := sec.MarshalOpenSSH(key, comment, passphrase)
+	if err != nil {
+		return err
+	}
+	pub, err := sec.MarshalAuthorizedKey(key, comment)
+	if err != nil {
+		return err
+	}
+	fp, err := sec.FingerprintSHA256(key)
+	if err != nil {
+		return err
+	}
+
+	p := cfg.SSH.File
+	if checkFile(p) || checkFile(p+".pub") {
+		log.Infof(sshKeyOverwrite, p)
+		choice, err := log.Confirm(sshKeyPrompt)
+		if err != nil {
+			return err
+		}
+		if choice == "n" {
+			return nil
+		}
+	}
+	if err := writeFile(p, priv); err != nil {
+		return err
+	}
+	if err := writeFile(p+".pub", pub); err != nil {
+		return err
+	}
+
+	if err := saveSite(peppers); err != nil {
+		return err
+	}
+
+	log.Infof(sshKeyDone, p, p+".pub", fp)
+	if cfg.SSH.Passphrase {
+		log.Infof(sshKeyPassphrase, pin, du)
+	}
+	return nil
+}
+
+func validateSSHKeyFlags(cmd *cobra.Command, args []string) error {
+	if cfg.Domain == "" {
+		return fmt.Errorf("domain name is required")
+	}
+	if cfg.SSH.File == "" {
+		return fmt.Errorf("output file is required")
+	}
+	return nil
+}
+
+// sshKeyCmd represents the ssh-key command
+var sshKeyCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Use:                   "ssh-key -d <HOST> [-u <USER>] [-p <PIN>] [-o <FILE>] [--passphrase]",
+	Short:                 "Generate SSH key pair",
+	Long: `
+Generate an ed25519 SSH key pair for the host and write the OpenSSH private key
+and the public key.
+
+The same key pair is generated again from your secret, salt, and the pepper for
+the host; a lost key can be recovered the same way as a password. Pick a
+different key with --pin.
+
+With --passphrase, the private key is encrypted with the password of the same
+PIN for the host, as shown by: spa pwd -d <HOST> -u <USER> -p <PIN>
+`,
+	Args: validateSSHKeyFlags,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := checkSaltSecret(); err != nil {
+			exit(err)
+		}
+
+		err := genSSHKey()
+		exit(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sshKeyCmd)
+
+	sshKeyCmd.Flags().StringVar(&cfg.Salt.Raw, "salt", "", "specify a salt to use, whitespaces are ignored. default: saved in ~/.spa/salt")
+	sshKeyCmd.Flags().StringVarP(&cfg.Secret.Raw, "secret", "s", "", "your secret")
+
+	sshKeyCmd.Flags().VarP(newDomainValue("", &cfg.Domain), "domain", "d", "host name of the server. case insensitive. e.g. example.com")
+	sshKeyCmd.Flags().StringVarP(&cfg.User, "user", "u", "", "optional username for the server.")
+	sshKeyCmd.Flags().VarP(newPinValue(-1, &cfg.Pin), "pin", "p", "optional number to pick the key, the first key is used if not provided.")
+	sshKeyCmd.Flags().StringVarP(&cfg.SSH.File, "out-file", "o", defaultSSHKeyFile, "private key file, the public key is written to the same file with .pub appended.")
+	sshKeyCmd.Flags().BoolVar(&cfg.SSH.Passphrase, "passphrase", false, "encrypt the private key with the password for the host.")
+
+	addNewSiteFlag(sshKeyCmd)
+
+	sshKeyCmd.MarkFlagRequired("domain")
+
+	sshKeyCmd.Flags().MarkHidden("salt")
+	sshKeyCmd.Flags().MarkHidden("secret")
+}
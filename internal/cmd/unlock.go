@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/gostones/spa/internal"
+	"github.com/gostones/spa/internal/log"
+)
+
+const unlockDone = `Unlocked for %v in the %s keyring. Run 'spa lock' to lock now.
+`
+
+func unlock() error {
+	if err := checkSalt(); err != nil {
+		return err
+	}
+	seeds, err := deriveSeeds()
+	if err != nil {
+		return err
+	}
+	defer seeds.Destroy()
+	if err := useSeeds(seeds.Bytes()); err != nil {
+		return err
+	}
+
+	// validate secret
+	if _, err := decryptSafe(cfg.Secret.Foil); err != nil {
+		return err
+	}
+
+	key, err := readKey()
+	if err != nil {
+		return err
+	}
+	k, err := cacheSeeds(key, cfg.Salt.Hash, seeds.Bytes(), cfg.Cache.TTL)
+	if err != nil {
+		return err
+	}
+	log.Infof(unlockDone, cfg.Cache.TTL, k.Name())
+	return nil
+}
+
+func validateUnlockFlags(cmd *cobra.Command, args []string) error {
+	if cfg.Cache.TTL <= 0 {
+		return internal.NewUsageErrorf("invalid ttl: %v", cfg.Cache.TTL)
+	}
+	return nil
+}
+
+// unlockCmd represents the unlock command
+var unlockCmd = &cobra.Command{
+	DisableFlagsInUseLine: true,
+	Use:                   "unlock [--ttl <DURATION>]",
+	Short:                 "Unlock your secret for a while",
+	Long: `
+Hash your secret once and keep the result for a while, so that the following
+commands do not ask for your secret or hash it again.
+
+The hashes are encrypted with a random session key and kept in the Linux kernel
+keyring, or in the Secret Service with secret-tool, until the --ttl expires or
+'spa lock' is run. The session key is saved in $XDG_RUNTIME_DIR, which is
+removed when you log out, or in the directory of your salt.
+
+A secret provided with SPA_SECRET or --secret-fd is used instead, if any.
+`,
+	Args: validateUnlockFlags,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := unlock()
+		exit(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(unlockCmd)
+
+	unlockCmd.Flags().DurationVar(&cfg.Cache.TTL, "ttl", defaultCacheTTL, "time to keep your secret unlocked")
+}
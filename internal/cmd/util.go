@@ -1,15 +1,19 @@
 package cmd
 
 import (
+	"context"
 	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"unicode"
 
+	"github.com/gostones/spa/internal"
 	"github.com/gostones/spa/internal/log"
 	"github.com/gostones/spa/internal/sec"
 )
@@ -25,8 +29,36 @@ func normalize(s string) string {
 }
 
 func checkSaltSecret() error {
+	if err := checkSalt(); err != nil {
+		return err
+	}
+
+	// the keys derived by spa unlock are used unless a secret is provided
+	if cfg.Secret.Raw == "" && cfg.Secret.FD < 0 {
+		key, err := readKey()
+		if err != nil {
+			return err
+		}
+		if seeds := cachedSeeds(key, cfg.Salt.Hash); seeds != nil {
+			defer seeds.Destroy()
+			return useSeeds(seeds.Bytes())
+		}
+	}
+
+	seeds, err := deriveSeeds()
+	if err != nil {
+		return err
+	}
+	defer seeds.Destroy()
+	return useSeeds(seeds.Bytes())
+}
+
+// checkSalt hashes the salt text or reads the salt file.
+func checkSalt() error {
 	if cfg.Salt.Raw != "" {
-		salt, err := hashSalt(normalizedSalt())
+		bar := log.NewProgressBar()
+		salt, err := hashSalt(context.Background(), normalizedSalt(), bar.Update)
+		bar.Done()
 		if err != nil {
 			return err
 		}
@@ -36,80 +68,137 @@ func checkSaltSecret() error {
 		if !checkFile(p) {
 			return fmt.Errorf("salt is required. please run 'spa salt -h' for details")
 		}
-		hash, err := readSaltFile(p)
+		hash, profile, err := readSaltFile(p)
 		if err != nil {
 			return err
 		}
-		cfg.Salt.Hash = hash
+		if err := useKDFProfile(profile); err != nil {
+			return err
+		}
+		cfg.Salt.Hash = keepSecret(sec.NewBufferFrom(hash))
 	}
+	return nil
+}
 
-	// TODO this has to run after salt hash
+// deriveSeeds reads the secret and hashes it with the salt. It has to run
+// after checkSalt.
+func deriveSeeds() (*sec.Buffer, error) {
 	if cfg.Secret.Raw == "" {
-		raw, err := log.PromptSecret(secretPrompt)
+		raw, err := readSecret(secretPrompt)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		cfg.Secret.Raw = raw
 	}
+	if len(cfg.Secret.Raw) < minSecretLen {
+		return nil, ErrSecretTooShort
+	}
+	// the string can not be wiped, it is dropped as soon as possible.
+	raw := sec.NewBufferFrom([]byte(cfg.Secret.Raw))
+	defer raw.Destroy()
+	cfg.Secret.Raw = ""
+
+	key, err := readKey()
+	if err != nil {
+		return nil, err
+	}
+	return secretSeeds(raw.Bytes(), key, cfg.Salt.Hash)
+}
 
-	secrets, err := hashSecret(cfg.Secret.Raw, cfg.Salt.Hash)
+// useSeeds derives the keys of the secret from the seeds.
+func useSeeds(seeds []byte) error {
+	secrets, err := expandSeeds(seeds)
 	if err != nil {
 		return err
 	}
 	cfg.Secret.Stock = secrets[0]
 	cfg.Secret.Foil = secrets[1]
-
 	return nil
 }
 
 func generator(codebook string) (func(string, string, string, int) ([]string, error), error) {
-	g := sec.KeyGen(codebook, cfg.Secret.Stock, cfg.Salt.Hash, hashKeyLen, keyGenIteration)
+	g := sec.KeyGen(codebook, cfg.Secret.Stock, cfg.Salt.Hash, hashKeyLen, sec.CurrentKDFProfile().KeyGenIteration)
 	if g == nil {
 		return nil, fmt.Errorf("failed to create password generator")
 	}
 	return g, nil
 }
 
-func spakdf(key, salt []byte, iteration int) ([]byte, error) {
-	ba, err := sec.SPAKDF(key, salt, masterKeyCount, hashKeyLen, iteration)
+func spakdf(ctx context.Context, key, salt []byte, iteration int, progress sec.Progress) (*sec.Buffer, error) {
+	ba, err := sec.SPAKDF(ctx, key, salt, masterKeyCount, hashKeyLen, iteration, progress)
 	if err != nil {
 		return nil, err
 	}
-	var h []byte
-	for _, k := range ba {
-		h = append(h, k...)
+	return packKeys(ba), nil
+}
+
+// expandKey derives the master keys from a SPA hash, the same as spakdf does.
+func expandKey(data []byte) (*sec.Buffer, error) {
+	ba, err := sec.KDF(context.Background(), data, masterKeyCount, hashKeyLen, nil)
+	if err != nil {
+		return nil, err
 	}
-	return h[0:], nil
+	return packKeys(ba), nil
 }
 
-func hashSalt(raw []byte) ([]byte, error) {
-	key, salt := raw[2:], raw[0:2]
-	return spakdf(key, salt, saltIteration)
+// packKeys moves the keys into a buffer.
+func packKeys(ba [][]byte) *sec.Buffer {
+	h := sec.NewBuffer(len(ba) * hashKeyLen)
+	for i, k := range ba {
+		copy(h.Bytes()[i*hashKeyLen:], k)
+		sec.Wipe(k)
+	}
+	return h
 }
 
-func hashSecret(raw string, salt []byte) ([][]byte, error) {
-	if len(raw) < minSecretLen {
-		return nil, ErrSecretTooShort
+// hashSalt returns the salt hash. It takes minutes with the legacy profile,
+// the progress is reported and it stops if ctx is done.
+func hashSalt(ctx context.Context, raw []byte, progress sec.Progress) ([]byte, error) {
+	key, salt := raw[2:], raw[0:2]
+	h, err := spakdf(ctx, key, salt, sec.CurrentKDFProfile().SaltIteration, progress)
+	if err != nil {
+		return nil, err
 	}
+	return keepSecret(h), nil
+}
 
-	key, err := readKey()
+func hashSecret(raw []byte, key, salt []byte) ([][]byte, error) {
+	seeds, err := secretSeeds(raw, key, salt)
 	if err != nil {
 		return nil, err
 	}
-	hash := sec.SPIHash([]byte(raw), key)
+	defer seeds.Destroy()
+	return expandSeeds(seeds.Bytes())
+}
+
+// secretSeeds returns the SPA hashes of the two secrets, from which their
+// master keys are derived. They are what spa unlock caches.
+func secretSeeds(raw []byte, key, salt []byte) (*sec.Buffer, error) {
+	if len(raw) < minSecretLen {
+		return nil, ErrSecretTooShort
+	}
+
+	hash := sec.SPIHash(raw, key)
+	defer sec.Wipe(hash)
 	// split so we have two secrets:
 	// one for encryption and the other for password generation
 	secrets := split2(hash)
 
 	var wg sync.WaitGroup
 
-	var keys [2][]byte
+	seeds := sec.NewBuffer(len(secrets) * hashKeyLen)
 	var errs [2]error
 
 	spa := func(i int, key []byte) {
 		defer wg.Done()
 		idx := sec.FNV(key, uint32(len(salt)-hashKeyLen))
-		keys[i], errs[i] = spakdf(key, salt[idx:idx+hashKeyLen], secretIteration)
+		h, err := sec.SPA(context.Background(), key, salt[idx:idx+hashKeyLen], hashKeyLen, sec.CurrentKDFProfile().SecretIteration, nil)
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		copy(seeds.Bytes()[i*hashKeyLen:], h)
+		sec.Wipe(h)
 	}
 
 	for i, v := range secrets {
@@ -121,22 +210,60 @@ func hashSecret(raw string, salt []byte) ([][]byte, error) {
 
 	for _, err := range errs {
 		if err != nil {
+			seeds.Destroy()
 			return nil, err
 		}
 	}
-	return keys[0:], nil
+	return seeds, nil
 }
 
-func readSaltFile(p string) ([]byte, error) {
+// expandSeeds derives the master keys of the two secrets, stock and foil.
+func expandSeeds(seeds []byte) ([][]byte, error) {
+	if len(seeds) != 2*hashKeyLen {
+		return nil, fmt.Errorf("invalid seeds length: %v", len(seeds))
+	}
+	var keys [][]byte
+	for i := 0; i < 2; i++ {
+		h, err := expandKey(seeds[i*hashKeyLen : (i+1)*hashKeyLen])
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, keepSecret(h))
+	}
+	return keys, nil
+}
+
+func readSaltFile(p string) ([]byte, string, error) {
 	b, err := ioutil.ReadFile(p)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	salt, err := hex.DecodeString(string(b))
+	return decodeSalt(b)
+}
+
+// decodeSalt returns the salt hash and the name of the KDF profile, empty
+// for the legacy profile.
+func decodeSalt(b []byte) ([]byte, string, error) {
+	s := strings.TrimSpace(string(b))
+	var profile string
+	if i := strings.Index(s, kdfProfileSep); i >= 0 {
+		profile, s = s[:i], s[i+1:]
+	}
+	salt, err := hex.DecodeString(s)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	return salt, profile, nil
+}
+
+// encodeSalt returns the content of the salt file, the hex encoded hash is
+// prefixed with the KDF profile unless it is the legacy one.
+func encodeSalt(salt []byte, profile string) []byte {
+	s := hex.EncodeToString(salt)
+	if profile != "" && profile != sec.LegacyKDFProfile {
+		s = profile + kdfProfileSep + s
 	}
-	return salt, nil
+	return []byte(s)
 }
 
 func catSaltFile(p string) error {
@@ -144,18 +271,51 @@ func catSaltFile(p string) error {
 	if err != nil {
 		return err
 	}
-	io.WriteString(os.Stdout, string(b))
-	io.WriteString(os.Stdout, "\n")
+	salt, profile, err := decodeSalt(b)
+	if err != nil {
+		return err
+	}
+	if profile == "" {
+		profile = sec.LegacyKDFProfile
+	}
+	result := &internal.SaltResult{
+		File:    p,
+		Profile: profile,
+		Hash:    hex.EncodeToString(salt),
+	}
+	printResult(result, func() {
+		io.WriteString(os.Stdout, strings.TrimSpace(string(b)))
+		io.WriteString(os.Stdout, "\n")
+	})
 	return nil
 }
 
-func saveSaltFile(p string, salt []byte) error {
+// saveSaltFile writes a temporary file and renames it so that the salt file is
+// either replaced as a whole or left unchanged.
+func saveSaltFile(p string, salt []byte, profile string) error {
 	perm := os.FileMode(0600)
-	s := hex.EncodeToString(salt)
-	if err := ioutil.WriteFile(p, []byte(s), perm); err != nil {
+	f, err := ioutil.TempFile(filepath.Dir(p), "."+filepath.Base(p)+"-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+
+	if _, err := f.Write(encodeSalt(salt, profile)); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
 		return err
 	}
-	return os.Chmod(p, perm)
+	if err := os.Chmod(tmp, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
 }
 
 func checkFile(p string) bool {
@@ -163,6 +323,101 @@ func checkFile(p string) bool {
 	return !os.IsNotExist(err)
 }
 
+// wipe zeroes the bytes in place.
+func wipe(b []byte) {
+	sec.Wipe(b)
+}
+
+// secretBuffers holds the derived keys in use until wipeSecrets.
+var (
+	secretMu      sync.Mutex
+	secretBuffers []*sec.Buffer
+)
+
+// keepSecret returns the bytes of the buffer, which is destroyed by
+// wipeSecrets.
+func keepSecret(r *sec.Buffer) []byte {
+	secretMu.Lock()
+	defer secretMu.Unlock()
+	secretBuffers = append(secretBuffers, r)
+	return r.Bytes()
+}
+
+// wipeSecrets zeroes the salt hash and the keys derived from the secret. They
+// must not be used afterwards.
+func wipeSecrets() {
+	secretMu.Lock()
+	defer secretMu.Unlock()
+	for _, r := range secretBuffers {
+		r.Destroy()
+	}
+	secretBuffers = nil
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// closeMatches returns up to max candidates within a small edit distance of s,
+// closest first.
+func closeMatches(s string, candidates []string, max int) []string {
+	limit := len(s) / 4
+	if limit < 2 {
+		limit = 2
+	}
+
+	type match struct {
+		s string
+		d int
+	}
+	var matches []match
+	for _, c := range candidates {
+		if d := editDistance(s, c); d <= limit {
+			matches = append(matches, match{c, d})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].d != matches[j].d {
+			return matches[i].d < matches[j].d
+		}
+		return matches[i].s < matches[j].s
+	})
+
+	var list []string
+	for i := 0; i < len(matches) && i < max; i++ {
+		list = append(list, matches[i].s)
+	}
+	return list
+}
+
 func split2(input []byte) [][]byte {
 	n := len(input)
 	x := (n * 3) / 10
@@ -170,15 +425,19 @@ func split2(input []byte) [][]byte {
 }
 
 func enterNewPassword() (string, error) {
-	raw, err := log.PromptSecret(secretNewPrompt)
+	raw, err := readSecret(secretNewPrompt)
 	if err != nil {
 		return "", err
 	}
 	if len(raw) < minSecretLen {
 		return "", ErrSecretTooShort
 	}
+	// a script writing to --secret-fd has no typos to catch
+	if cfg.Secret.FD >= 0 {
+		return raw, nil
+	}
 
-	rawAgain, err := log.PromptSecret(secretNewAgainPrompt)
+	rawAgain, err := readSecret(secretNewAgainPrompt)
 	if err != nil {
 		return "", err
 	}
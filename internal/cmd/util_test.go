@@ -1,7 +1,15 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/gostones/spa/internal/sec"
 )
 
 func TestNormalize(t *testing.T) {
@@ -35,3 +43,125 @@ func TestSplit2(t *testing.T) {
 		t.Logf("[%v] a: %s b: %s", i, string(s[0]), string(s[1]))
 	}
 }
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"example.com:", "example.com:", 0},
+		{"exmaple.com:", "example.com:", 2},
+		{"exampel.com:", "example.com:", 2},
+		{"example.co:", "example.com:", 1},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"日本", "日本語", 1},
+	}
+	for i, tc := range tests {
+		if got := editDistance(tc.a, tc.b); got != tc.expected {
+			t.Fatalf("[%v] got: %v want: %v", i, got, tc.expected)
+		}
+	}
+}
+
+func TestCloseMatches(t *testing.T) {
+	candidates := []string{"example.com:", "example.org:", "example.com:bob", "bank.com:", "github.com:alice"}
+	tests := []struct {
+		s        string
+		max      int
+		expected []string
+	}{
+		{"exmaple.com:", 3, []string{"example.com:"}},
+		{"example.com:bb", 3, []string{"example.com:bob", "example.com:"}},
+		{"example.com:bo", 1, []string{"example.com:bob"}},
+		{"bnak.com:", 3, []string{"bank.com:"}},
+		{"unknown.net:", 3, nil},
+		{"example.co:", 3, []string{"example.com:"}},
+	}
+	for i, tc := range tests {
+		got := closeMatches(tc.s, candidates, tc.max)
+		if strings.Join(got, ",") != strings.Join(tc.expected, ",") {
+			t.Fatalf("[%v] got: %v want: %v", i, got, tc.expected)
+		}
+	}
+}
+
+func TestSaltEncoding(t *testing.T) {
+	salt := []byte{0x01, 0xab, 0xff}
+	tests := []struct {
+		profile  string
+		content  string
+		expected string
+	}{
+		{"", "01abff", ""},
+		{sec.LegacyKDFProfile, "01abff", ""},
+		{"paranoid", "paranoid:01abff", "paranoid"},
+	}
+	for i, tc := range tests {
+		b := encodeSalt(salt, tc.profile)
+		if string(b) != tc.content {
+			t.Fatalf("[%v] got: %s want: %s", i, b, tc.content)
+		}
+		got, profile, err := decodeSalt(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, salt) || profile != tc.expected {
+			t.Fatalf("[%v] got: %x %q want: %x %q", i, got, profile, salt, tc.expected)
+		}
+	}
+
+	if err := useKDFProfile("unknown"); err == nil {
+		t.Fatal("expected error with unknown profile")
+	}
+}
+
+func TestSaveSaltFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := filepath.Join(dir, "salt")
+	for _, salt := range [][]byte{{0x01, 0x02}, {0x03, 0x04}} {
+		if err := saveSaltFile(p, salt, "paranoid"); err != nil {
+			t.Fatal(err)
+		}
+		got, profile, err := readSaltFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, salt) || profile != "paranoid" {
+			t.Fatalf("got: %x %q want: %x %q", got, profile, salt, "paranoid")
+		}
+	}
+
+	fi, err := os.Stat(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Fatalf("got: %v want: %v", fi.Mode().Perm(), os.FileMode(0600))
+	}
+
+	// the temporary files are gone
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("got: %v want: %v", len(files), 1)
+	}
+}
+
+func TestHashSaltCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := hashSalt(ctx, []byte("a salt long enough"), nil); err != context.Canceled {
+		t.Fatalf("got: %v want: %v", err, context.Canceled)
+	}
+}
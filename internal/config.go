@@ -1,5 +1,9 @@
 package internal
 
+import (
+	"time"
+)
+
 const (
 	ClientName  = "spa"
 	ProductName = "Secure password assistant"
@@ -12,43 +16,164 @@ var (
 
 type Configuration struct {
 	BaseDir string
-	// Server  ServerConfig
-	Domain string
-	User   string
-	Secret SecretDigest
-	Salt   SaltDigest
+	Output  string
+	Server  ServerConfig
+	Domain  string
+	User    string
+	Secret  SecretDigest
+	Salt    SaltDigest
 
 	Pin      int
 	Pwd      PwdConfig
+	Policy   PolicyConfig
+	Phrase   PhraseConfig
+	Code     CodeConfig
+	OTP      OTPConfig
+	SSH      SSHConfig
+	Age      AgeConfig
+	Site     SiteConfig
+	Clip     ClipConfig
+	View     ViewConfig
+	Bench    BenchConfig
+	Cache    CacheConfig
 	Question QuestionConfig
 	Count    int
+
+	Backup BackupConfig
 }
 
 type SaltDigest struct {
 	Raw  string
 	Hash []byte
+
+	// name of the KDF profile
+	Profile string
 }
 
 type SecretDigest struct {
 	Raw    string
 	NewRaw string
 
+	// file descriptor to read the secret from, -1 if not provided.
+	FD int
+
 	// secret is split into two parts
 	Stock []byte
 	Foil  []byte
 }
 
 type PwdConfig struct {
+	Pepper string        `json:"pepper"`
+	Mask   string        `json:"mask"`
+	Length int           `json:"length"`
+	Note   string        `json:"note"`
+	Preset string        `json:"preset,omitempty"`
+	Policy *PolicyConfig `json:"policy,omitempty"`
+	Phrase *PhraseConfig `json:"phrase,omitempty"`
+	TOTP   *TOTPConfig   `json:"totp,omitempty"`
+
+	// Origin is the domain:user the passwords are generated for if the site
+	// has been renamed.
+	Origin string `json:"origin,omitempty"`
+}
+
+// PwdRevision is a past config of a site, replaced at the given time.
+type PwdRevision struct {
+	Replaced time.Time `json:"replaced"`
+	Config   PwdConfig `json:"config"`
+}
+
+// TOTPConfig is the time-based one-time password seed of a site.
+type TOTPConfig struct {
+	Secret    string `json:"secret"`
+	Algorithm string `json:"algorithm"`
+	Digits    int    `json:"digits"`
+	Period    int    `json:"period"`
+	Issuer    string `json:"issuer,omitempty"`
+	Account   string `json:"account,omitempty"`
+}
+
+// PhraseConfig is the passphrase format of a site.
+type PhraseConfig struct {
+	Words     int    `json:"words"`
+	Separator string `json:"separator"`
+}
+
+// PolicyConfig is the password policy of a site.
+type PolicyConfig struct {
+	MinUpper  int    `json:"minUpper,omitempty"`
+	MinLower  int    `json:"minLower,omitempty"`
+	MinDigit  int    `json:"minDigit,omitempty"`
+	MinSymbol int    `json:"minSymbol,omitempty"`
+	MaxRepeat int    `json:"maxRepeat,omitempty"`
+	Symbols   string `json:"symbols,omitempty"`
+}
+
+// CodeConfig is the numeric code config of a site, kept apart from passwords.
+type CodeConfig struct {
 	Pepper string `json:"pepper"`
-	Mask   string `json:"mask"`
 	Length int    `json:"length"`
 	Note   string `json:"note"`
+	Origin string `json:"origin,omitempty"`
+}
+
+type OTPConfig struct {
+	URI string
+}
+
+type SSHConfig struct {
+	File       string
+	Passphrase bool
+}
+
+type AgeConfig struct {
+	Label      string
+	Recipients []string
+	Identity   bool
+	Input      string
+	Output     string
+}
+
+type SiteConfig struct {
+	Domain string
+	User   string
+	New    bool
+
+	Revision int
+}
+
+type CacheConfig struct {
+	TTL time.Duration
+}
+
+type BenchConfig struct {
+	Profile string
+	Runs    int
+	Target  time.Duration
+}
+
+type ViewConfig struct {
+	Masked bool
+	Reveal bool
+}
+
+type ClipConfig struct {
+	Copy  bool
+	Clear time.Duration
 }
 
 type QuestionConfig struct {
 	Question string `json:"-"`
 }
 
+type BackupConfig struct {
+	File       string
+	Passphrase string
+	Paper      bool
+}
+
 type ServerConfig struct {
-	Port int
+	Port   int
+	Socket string
+	Idle   time.Duration
 }
@@ -1,26 +1,80 @@
 package format
 
 import (
+	"fmt"
+	"io"
 	"os"
+	"strings"
 	"text/tabwriter"
 	"text/template"
+	"time"
 
+	"github.com/gostones/spa/internal"
 	"github.com/gostones/spa/internal/log"
 )
 
+const (
+	pwdTpl = `PIN	PASSWORD
+{{range .Passwords}}{{printf "%04v" .PIN}}	{{.Value}}
+{{end}}`
+
+	sqTpl = `PIN	ANSWER
+{{range .Answers}}{{printf "%04v" .PIN}}	{{.Value}}
+{{end}}`
+
+	siteListTpl = `DOMAIN	USER	LENGTH	MASK	NOTE	PRESET
+{{range .Sites}}{{.Domain}}	{{.User}}	{{with .Config}}{{.Length}}	{{printf "%q" .Mask}}	{{.Note}}	{{.Preset}}{{else}}-	-	alias of {{.Alias}}	-{{end}}
+{{end}}`
+
+	saltTpl = `FILE	PROFILE	HASH
+{{.File}}	{{.Profile}}	{{.Hash}}
+`
+
+	benchTpl = `PROFILE	{{with index .Profiles 0}}{{range .Algorithms}}{{upper .Name}}	{{end}}{{end}}SALT	SECRET	KEYGEN	CRYPT	COMMAND	GUESS	MEMORY
+{{range .Profiles}}{{.Profile}}{{if .Current}} *{{end}}	{{range .Algorithms}}{{duration .Duration}}	{{end}}{{duration .Salt}}	{{duration .Secret}}	{{duration .KeyGen}}	{{duration .Crypt}}	{{duration .Command}}	{{duration .Guess}}	{{mib .GuessMemory}}
+{{end}}`
+)
+
+var funcs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"duration": func(d time.Duration) string {
+		if d >= time.Second {
+			return d.Round(100 * time.Millisecond).String()
+		}
+		return d.Round(time.Millisecond).String()
+	},
+	"mib": func(n int64) string {
+		return fmt.Sprintf("%vMiB", n>>20)
+	},
+}
+
 // PrintTab prints data in tabular form.
 func PrintTab(data interface{}) {
+	FprintTab(os.Stdout, data)
+}
+
+// FprintTab writes data in tabular form to w.
+func FprintTab(w io.Writer, data interface{}) {
 	var tpl string
-	t := template.New("tab")
-	// switch data.(type) {
-	// default:
-	// 	log.Errorln("formatting template not found")
-	// 	return
-	// }
-	t, _ = t.Parse(tpl)
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
-	if err := t.Execute(w, data); err != nil {
+	switch data.(type) {
+	case *internal.PwdResult:
+		tpl = pwdTpl
+	case *internal.SQResult:
+		tpl = sqTpl
+	case *internal.SiteListResult:
+		tpl = siteListTpl
+	case *internal.SaltResult:
+		tpl = saltTpl
+	case *internal.BenchResult:
+		tpl = benchTpl
+	default:
+		log.Errorln("formatting template not found")
+		return
+	}
+	t := template.Must(template.New("tab").Funcs(funcs).Parse(tpl))
+	tw := tabwriter.NewWriter(w, 0, 0, 4, ' ', 0)
+	if err := t.Execute(tw, data); err != nil {
 		log.Errorln(err)
 	}
-	w.Flush()
+	tw.Flush()
 }
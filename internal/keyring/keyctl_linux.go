@@ -0,0 +1,148 @@
+//go:build linux
+// +build linux
+
+package keyring
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// https://man7.org/linux/man-pages/man2/keyctl.2.html
+const (
+	keySpecUserKeyring = -4
+
+	keyctlGetKeyringID = 0
+	keyctlSetPerm      = 5
+	keyctlUnlink       = 9
+	keyctlSearch       = 10
+	keyctlRead         = 11
+	keyctlSetTimeout   = 15
+	keyctlInvalidate   = 21
+
+	// possessor and user: view, read, write, search, link, setattr.
+	// group and other: none.
+	keyPerm = 0x3f3f0000
+
+	keyType = "user"
+)
+
+// keyctl stores the values as user keys in the user keyring, which is kept by
+// the kernel until the key expires or the user logs out.
+type keyctl struct {
+	ring int
+}
+
+func newKeyctl() (Keyring, bool) {
+	// the keyring is created if it does not exist
+	spec := keySpecUserKeyring
+	id, err := keyctlCall(keyctlGetKeyringID, uintptr(spec), 1)
+	if err != nil {
+		return nil, false
+	}
+	return &keyctl{ring: id}, true
+}
+
+func keyctlCall(cmd int, args ...uintptr) (int, error) {
+	var a [4]uintptr
+	copy(a[:], args)
+	r, _, errno := syscall.Syscall6(syscall.SYS_KEYCTL, uintptr(cmd), a[0], a[1], a[2], a[3], 0)
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(r), nil
+}
+
+func (r *keyctl) Name() string {
+	return "keyctl"
+}
+
+func (r *keyctl) search(name string) (int, error) {
+	t, err := syscall.BytePtrFromString(keyType)
+	if err != nil {
+		return 0, err
+	}
+	d, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return 0, err
+	}
+	id, err := keyctlCall(keyctlSearch, uintptr(r.ring), uintptr(unsafe.Pointer(t)), uintptr(unsafe.Pointer(d)), 0)
+	switch err {
+	case nil:
+		return id, nil
+	case syscall.ENOKEY, syscall.EKEYEXPIRED, syscall.EKEYREVOKED:
+		return 0, ErrNotFound
+	}
+	return 0, fmt.Errorf("keyctl search: %v", err)
+}
+
+func (r *keyctl) Get(name string) ([]byte, error) {
+	id, err := r.search(name)
+	if err != nil {
+		return nil, err
+	}
+	// the size is returned if the buffer is too small
+	n, err := keyctlCall(keyctlRead, uintptr(id), 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("keyctl read: %v", err)
+	}
+	b := make([]byte, n)
+	if n == 0 {
+		return b, nil
+	}
+	m, err := keyctlCall(keyctlRead, uintptr(id), uintptr(unsafe.Pointer(&b[0])), uintptr(n))
+	if err != nil {
+		return nil, fmt.Errorf("keyctl read: %v", err)
+	}
+	if m < n {
+		b = b[:m]
+	}
+	return b, nil
+}
+
+func (r *keyctl) Set(name string, value []byte, ttl time.Duration) error {
+	t, err := syscall.BytePtrFromString(keyType)
+	if err != nil {
+		return err
+	}
+	d, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return err
+	}
+	var p unsafe.Pointer
+	if len(value) > 0 {
+		p = unsafe.Pointer(&value[0])
+	}
+	// an existing key of the same name is updated
+	id, _, errno := syscall.Syscall6(syscall.SYS_ADD_KEY, uintptr(unsafe.Pointer(t)), uintptr(unsafe.Pointer(d)), uintptr(p), uintptr(len(value)), uintptr(r.ring), 0)
+	if errno != 0 {
+		return fmt.Errorf("keyctl add: %v", errno)
+	}
+	if _, err := keyctlCall(keyctlSetPerm, id, keyPerm); err != nil {
+		r.Delete(name)
+		return fmt.Errorf("keyctl setperm: %v", err)
+	}
+	secs := (ttl + time.Second - 1) / time.Second
+	if _, err := keyctlCall(keyctlSetTimeout, id, uintptr(secs)); err != nil {
+		r.Delete(name)
+		return fmt.Errorf("keyctl timeout: %v", err)
+	}
+	return nil
+}
+
+func (r *keyctl) Delete(name string) error {
+	id, err := r.search(name)
+	if err != nil {
+		return err
+	}
+	if _, err := keyctlCall(keyctlInvalidate, uintptr(id)); err == nil {
+		return nil
+	}
+	// invalidate is not supported before Linux 3.5
+	if _, err := keyctlCall(keyctlUnlink, uintptr(id), uintptr(r.ring)); err != nil {
+		return fmt.Errorf("keyctl unlink: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package keyring
+
+// the kernel keyring is only available on Linux.
+func newKeyctl() (Keyring, bool) {
+	return nil, false
+}
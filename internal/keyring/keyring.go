@@ -0,0 +1,103 @@
+// Package keyring keeps secrets for a limited time in the Linux kernel keyring
+// or in the Secret Service, e.g. GNOME Keyring or KWallet, with secret-tool.
+package keyring
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnavailable is returned if no keyring is available.
+var ErrUnavailable = errors.New("keyring not found. please use Linux or install secret-tool for the Secret Service")
+
+// ErrNotFound is returned if the key does not exist or has expired.
+var ErrNotFound = errors.New("key not found")
+
+// Keyring stores values by name. A value expires after its time to live.
+type Keyring interface {
+	Name() string
+	Get(name string) ([]byte, error)
+	Set(name string, value []byte, ttl time.Duration) error
+	Delete(name string) error
+}
+
+// secretTool stores the values in the Secret Service with secret-tool. The
+// Secret Service does not expire values, the expiry is stored along with the
+// value and checked when read.
+type secretTool struct {
+	program string
+}
+
+func (r *secretTool) Name() string {
+	return "secret-service"
+}
+
+func (r *secretTool) attributes(name string) []string {
+	return []string{"application", "spa", "name", name}
+}
+
+func (r *secretTool) Get(name string) ([]byte, error) {
+	args := append([]string{"lookup"}, r.attributes(name)...)
+	b, err := exec.Command(r.program, args...).Output()
+	if err != nil || len(b) == 0 {
+		// secret-tool exits with 1 if nothing is found
+		if _, ok := err.(*exec.ExitError); ok || err == nil {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("%s: %v", r.program, err)
+	}
+
+	// <expiry>:<base64 value>
+	s := strings.SplitN(strings.TrimSpace(string(b)), ":", 2)
+	if len(s) != 2 {
+		return nil, fmt.Errorf("%s: invalid value of %q", r.program, name)
+	}
+	expiry, err := strconv.ParseInt(s[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid expiry of %q: %v", r.program, name, err)
+	}
+	if time.Now().Unix() >= expiry {
+		r.Delete(name)
+		return nil, ErrNotFound
+	}
+	return base64.StdEncoding.DecodeString(s[1])
+}
+
+func (r *secretTool) Set(name string, value []byte, ttl time.Duration) error {
+	args := append([]string{"store", "--label", "spa " + name}, r.attributes(name)...)
+	cmd := exec.Command(r.program, args...)
+	expiry := time.Now().Add(ttl).Unix()
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("%v:%s", expiry, base64.StdEncoding.EncodeToString(value)))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %v %s", r.program, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (r *secretTool) Delete(name string) error {
+	args := append([]string{"clear"}, r.attributes(name)...)
+	if out, err := exec.Command(r.program, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %v %s", r.program, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Detect returns the kernel keyring if available, or the Secret Service if
+// secret-tool is installed and a D-Bus session is running.
+func Detect() (Keyring, error) {
+	if k, ok := newKeyctl(); ok {
+		return k, nil
+	}
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") != "" {
+		if p, err := exec.LookPath("secret-tool"); err == nil {
+			return &secretTool{program: p}, nil
+		}
+	}
+	return nil, ErrUnavailable
+}
@@ -0,0 +1,79 @@
+package keyring
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func testKeyring(t *testing.T, k Keyring) {
+	name := fmt.Sprintf("spa-test:%v", os.Getpid())
+	defer k.Delete(name)
+
+	if _, err := k.Get(name); err != ErrNotFound {
+		t.Fatalf("got: %v want: %v", err, ErrNotFound)
+	}
+
+	for _, v := range []string{"secret", "another secret"} {
+		if err := k.Set(name, []byte(v), time.Minute); err != nil {
+			t.Fatal(err)
+		}
+		b, err := k.Get(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != v {
+			t.Fatalf("got: %q want: %q", b, v)
+		}
+	}
+
+	if err := k.Delete(name); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k.Get(name); err != ErrNotFound {
+		t.Fatalf("got: %v want: %v", err, ErrNotFound)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	k := NewMemory()
+	testKeyring(t, k)
+
+	now := time.Now()
+	k.now = func() time.Time {
+		return now
+	}
+	if err := k.Set("expiring", []byte("secret"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(59 * time.Second)
+	if _, err := k.Get("expiring"); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Second)
+	if _, err := k.Get("expiring"); err != ErrNotFound {
+		t.Fatalf("got: %v want: %v", err, ErrNotFound)
+	}
+}
+
+func TestKeyctl(t *testing.T) {
+	k, ok := newKeyctl()
+	if !ok {
+		t.Skip("kernel keyring not available")
+	}
+	testKeyring(t, k)
+
+	if testing.Short() {
+		return
+	}
+	name := fmt.Sprintf("spa-test-ttl:%v", os.Getpid())
+	defer k.Delete(name)
+	if err := k.Set(name, []byte("secret"), time.Second); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(1500 * time.Millisecond)
+	if _, err := k.Get(name); err != ErrNotFound {
+		t.Fatalf("got: %v want: %v", err, ErrNotFound)
+	}
+}
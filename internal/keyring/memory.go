@@ -0,0 +1,68 @@
+package keyring
+
+import (
+	"sync"
+	"time"
+)
+
+// Memory is a keyring in memory, e.g. for tests.
+type Memory struct {
+	mu    sync.Mutex
+	items map[string]memoryItem
+
+	now func() time.Time
+}
+
+type memoryItem struct {
+	value  []byte
+	expiry time.Time
+}
+
+// NewMemory creates an empty keyring in memory.
+func NewMemory() *Memory {
+	return &Memory{
+		items: make(map[string]memoryItem),
+		now:   time.Now,
+	}
+}
+
+func (r *Memory) Name() string {
+	return "memory"
+}
+
+func (r *Memory) Get(name string) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	it, ok := r.items[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if !r.now().Before(it.expiry) {
+		delete(r.items, name)
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), it.value...), nil
+}
+
+func (r *Memory) Set(name string, value []byte, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.items[name] = memoryItem{
+		value:  append([]byte(nil), value...),
+		expiry: r.now().Add(ttl),
+	}
+	return nil
+}
+
+func (r *Memory) Delete(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.items[name]; !ok {
+		return ErrNotFound
+	}
+	delete(r.items, name)
+	return nil
+}
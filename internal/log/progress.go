@@ -0,0 +1,78 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const (
+	progressWidth    = 30
+	progressInterval = 200 * time.Millisecond
+)
+
+// ProgressBar draws the progress of a long running job with the estimated time
+// left. Nothing is drawn if the standard error is not a terminal.
+type ProgressBar struct {
+	out   io.Writer
+	on    bool
+	start time.Time
+	drawn time.Time
+}
+
+// NewProgressBar creates a progress bar, the time left is estimated from now.
+func NewProgressBar() *ProgressBar {
+	return &ProgressBar{
+		out:   os.Stderr,
+		on:    term.IsTerminal(int(os.Stderr.Fd())),
+		start: time.Now(),
+	}
+}
+
+// Update draws the bar with done out of total steps. It can be passed to
+// sec.SPAKDF as the progress callback.
+func (r *ProgressBar) Update(done, total int) {
+	if !r.on || total <= 0 {
+		return
+	}
+	now := time.Now()
+	if done < total && now.Sub(r.drawn) < progressInterval {
+		return
+	}
+	r.drawn = now
+	fmt.Fprintf(r.out, "\r%s", progressLine(done, total, now.Sub(r.start)))
+}
+
+// Done ends the line of the bar.
+func (r *ProgressBar) Done() {
+	if !r.on || r.drawn.IsZero() {
+		return
+	}
+	fmt.Fprintln(r.out)
+}
+
+// progressLine returns the bar, e.g. [=====>    ]  50% ETA 1m30s
+func progressLine(done, total int, elapsed time.Duration) string {
+	if done > total {
+		done = total
+	}
+	n := progressWidth * done / total
+	bar := strings.Repeat("=", n)
+	if n < progressWidth {
+		bar += ">" + strings.Repeat(" ", progressWidth-n-1)
+	}
+
+	eta := "--"
+	if done == total {
+		eta = "0s"
+	} else if done > 0 {
+		left := elapsed * time.Duration(total-done) / time.Duration(done)
+		eta = left.Round(time.Second).String()
+	}
+	// pad to clear the rest of a longer line drawn before
+	return fmt.Sprintf("[%s] %3d%% ETA %-10s", bar, 100*done/total, eta)
+}
@@ -2,6 +2,8 @@ package log
 
 import (
 	"bufio"
+	"errors"
+	"fmt"
 	"os"
 	"strings"
 	"syscall"
@@ -64,3 +66,45 @@ func PromptSecret(ps string) (string, error) {
 	Promptln()
 	return string(b), nil
 }
+
+// Ask prompts for a line of input.
+func Ask(ps string) (string, error) {
+	Promptf(ps)
+
+	v, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(v), nil
+}
+
+const (
+	altScreenOn  = "\x1b[?1049h\x1b[H\x1b[2J"
+	altScreenOff = "\x1b[?1049l"
+)
+
+// Reveal shows the text in the alternate screen buffer of the terminal until a
+// key is pressed. The text is not left in the scrollback once the screen is
+// switched back.
+func Reveal(s string, ps string) error {
+	fd := int(syscall.Stdin)
+	if !term.IsTerminal(fd) || !term.IsTerminal(int(os.Stderr.Fd())) {
+		return errors.New("terminal is required to reveal")
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	fmt.Fprint(os.Stderr, altScreenOn)
+	defer fmt.Fprint(os.Stderr, altScreenOff)
+
+	// no output processing in raw mode
+	s = strings.ReplaceAll(s, "\n", "\r\n")
+	fmt.Fprintf(os.Stderr, "%s\r\n\r\n%s", s, ps)
+
+	b := make([]byte, 1)
+	_, err = os.Stdin.Read(b)
+	return err
+}
@@ -0,0 +1,163 @@
+// Package pinentry reads a secret with a pinentry program over the Assuan
+// protocol, as used by GnuPG.
+package pinentry
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ErrCancelled is returned if the dialog is cancelled or closed.
+var ErrCancelled = errors.New("pinentry cancelled")
+
+// Assuan error code of GPG_ERR_CANCELED
+const errCanceled = 99
+
+// Options of the dialog.
+type Options struct {
+	Title  string
+	Desc   string
+	Prompt string
+}
+
+// Read runs the pinentry program and reads the secret.
+func Read(program string, o Options) (string, error) {
+	cmd := exec.Command(program)
+	cmd.Stderr = os.Stderr
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return "", err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+
+	pin, err := GetPin(out, in, o)
+	in.Close()
+	if werr := cmd.Wait(); err == nil && werr != nil {
+		err = fmt.Errorf("%s: %v", program, werr)
+	}
+	return pin, err
+}
+
+// GetPin talks to the pinentry reading its responses from r and writing
+// commands to w.
+func GetPin(r io.Reader, w io.Writer, o Options) (string, error) {
+	br := bufio.NewReader(r)
+
+	// greeting
+	if _, err := response(br); err != nil {
+		return "", err
+	}
+
+	var options []string
+	if v := os.Getenv("GPG_TTY"); v != "" {
+		options = append(options, "OPTION ttyname="+v)
+	}
+	if v := os.Getenv("TERM"); v != "" {
+		options = append(options, "OPTION ttytype="+v)
+	}
+	for _, c := range []struct{ cmd, arg string }{
+		{"SETTITLE", o.Title},
+		{"SETDESC", o.Desc},
+		{"SETPROMPT", o.Prompt},
+	} {
+		if c.arg != "" {
+			options = append(options, c.cmd+" "+escape(c.arg))
+		}
+	}
+	for _, c := range options {
+		if err := send(w, c); err != nil {
+			return "", err
+		}
+		if _, err := response(br); err != nil {
+			return "", err
+		}
+	}
+
+	if err := send(w, "GETPIN"); err != nil {
+		return "", err
+	}
+	pin, err := response(br)
+	if err != nil {
+		return "", err
+	}
+	send(w, "BYE")
+	return pin, nil
+}
+
+func send(w io.Writer, cmd string) error {
+	_, err := io.WriteString(w, cmd+"\n")
+	return err
+}
+
+// response reads the lines until OK or ERR and returns the data lines.
+func response(r *bufio.Reader) (string, error) {
+	var data strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", fmt.Errorf("pinentry: %v", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case line == "OK" || strings.HasPrefix(line, "OK "):
+			return data.String(), nil
+		case strings.HasPrefix(line, "ERR "):
+			return "", parseError(line[4:])
+		case strings.HasPrefix(line, "D "):
+			s, err := unescape(line[2:])
+			if err != nil {
+				return "", err
+			}
+			data.WriteString(s)
+		}
+		// status and comment lines are ignored
+	}
+}
+
+// parseError parses "<code> <description>" of the ERR line.
+func parseError(s string) error {
+	sa := strings.SplitN(s, " ", 2)
+	code, err := strconv.Atoi(sa[0])
+	if err == nil && code&0xffff == errCanceled {
+		return ErrCancelled
+	}
+	return fmt.Errorf("pinentry: %s", s)
+}
+
+// escape percent-encodes the characters not allowed in an argument.
+func escape(s string) string {
+	r := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	return r.Replace(s)
+}
+
+func unescape(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+2 >= len(s) {
+			return "", fmt.Errorf("pinentry: invalid data: %q", s)
+		}
+		v, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", fmt.Errorf("pinentry: invalid data: %q", s)
+		}
+		b.WriteByte(byte(v))
+		i += 2
+	}
+	return b.String(), nil
+}
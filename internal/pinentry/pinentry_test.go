@@ -0,0 +1,76 @@
+package pinentry
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+// serve answers the commands like a pinentry program.
+func serve(r io.Reader, w io.Writer, pin string) []string {
+	var cmds []string
+	io.WriteString(w, "OK Pleased to meet you\n")
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		cmd := s.Text()
+		cmds = append(cmds, cmd)
+		switch {
+		case cmd == "GETPIN" && pin == "":
+			io.WriteString(w, "ERR 83886179 Operation cancelled <Pinentry>\n")
+		case cmd == "GETPIN":
+			io.WriteString(w, "S PASSWORD_FROM_CACHE\n# comment\nD "+pin+"\nOK\n")
+		case cmd == "BYE":
+			io.WriteString(w, "OK closing connection\n")
+			return cmds
+		default:
+			io.WriteString(w, "OK\n")
+		}
+	}
+	return cmds
+}
+
+func TestGetPin(t *testing.T) {
+	os.Unsetenv("GPG_TTY")
+	os.Unsetenv("TERM")
+
+	tests := []struct {
+		data string
+		want string
+		err  error
+	}{
+		{"secret", "secret", nil},
+		{"100%25 s%0Acret", "100% s\ncret", nil},
+		{"", "", ErrCancelled},
+	}
+
+	for i, test := range tests {
+		cr, cw := io.Pipe()
+		rr, rw := io.Pipe()
+		done := make(chan []string)
+		go func() {
+			done <- serve(cr, rw, test.data)
+			rw.Close()
+		}()
+
+		got, err := GetPin(rr, cw, Options{Desc: "Enter 100% of\nyour secret", Prompt: "Secret:"})
+		go io.Copy(ioutil.Discard, rr)
+		cw.Close()
+		cmds := <-done
+		if err != test.err {
+			t.Fatalf("[%v] err: %v want: %v", i, err, test.err)
+		}
+		if got != test.want {
+			t.Fatalf("[%v] got: %q want: %q", i, got, test.want)
+		}
+		want := "SETDESC Enter 100%25 of%0Ayour secret,SETPROMPT Secret:,GETPIN"
+		if err == nil {
+			want += ",BYE"
+		}
+		if s := strings.Join(cmds, ","); s != want {
+			t.Fatalf("[%v] got: %v want: %v", i, s, want)
+		}
+	}
+}
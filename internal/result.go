@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"time"
+)
+
+// Candidate is a generated password or answer and its PIN.
+type Candidate struct {
+	PIN   int    `json:"pin"`
+	Value string `json:"value"`
+}
+
+// PwdResult is the output of the pwd command.
+type PwdResult struct {
+	Domain    string      `json:"domain"`
+	User      string      `json:"user"`
+	Revision  int         `json:"revision,omitempty"`
+	Passwords []Candidate `json:"passwords"`
+}
+
+// SQResult is the output of the sq command.
+type SQResult struct {
+	Domain   string      `json:"domain"`
+	User     string      `json:"user"`
+	Question string      `json:"question"`
+	Answers  []Candidate `json:"answers"`
+}
+
+// SiteResult is a site saved in the pepper file. Alias is the domain:user of
+// the record if the site is an alias.
+type SiteResult struct {
+	Domain string     `json:"domain"`
+	User   string     `json:"user"`
+	Alias  string     `json:"alias,omitempty"`
+	Config *PwdConfig `json:"config,omitempty"`
+}
+
+// SiteListResult is the output of the pepper command.
+type SiteListResult struct {
+	Sites []SiteResult `json:"sites"`
+}
+
+// SaltResult is the output of the salt cat command.
+type SaltResult struct {
+	File    string `json:"file"`
+	Profile string `json:"profile"`
+	Hash    string `json:"hash"`
+}
+
+// BenchAlgorithm is the time of a run of a password hashing algorithm.
+type BenchAlgorithm struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+}
+
+// BenchProfile is the estimated cost of a KDF profile on this machine, in
+// nanoseconds and bytes.
+type BenchProfile struct {
+	Profile    string           `json:"profile"`
+	Current    bool             `json:"current,omitempty"`
+	Algorithms []BenchAlgorithm `json:"algorithms"`
+
+	// stages
+	Salt   time.Duration `json:"salt"`
+	Secret time.Duration `json:"secret"`
+	KeyGen time.Duration `json:"keygen"`
+	Crypt  time.Duration `json:"crypt"`
+
+	// time to generate passwords for a site, i.e. spa pwd
+	Command time.Duration `json:"command"`
+
+	// attacker cost of each guess of the secret
+	Guess       time.Duration `json:"guess"`
+	GuessMemory int64         `json:"guessMemory"`
+}
+
+// BenchResult is the output of the bench command.
+type BenchResult struct {
+	Profiles    []BenchProfile `json:"profiles"`
+	Target      time.Duration  `json:"target"`
+	Recommended string         `json:"recommended,omitempty"`
+}
@@ -0,0 +1,416 @@
+package sec
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+// age v1 file format with X25519 recipients.
+// https://age-encryption.org/v1
+const (
+	ageIntro       = "age-encryption.org/v1"
+	ageX25519Label = "age-encryption.org/v1/X25519"
+	ageX25519Type  = "X25519"
+	ageFooter      = "---"
+
+	AgeIdentityHRP  = "AGE-SECRET-KEY-"
+	AgeRecipientHRP = "age"
+
+	ageFileKeySize = 16
+	ageNonceSize   = 16
+	ageChunkSize   = 64 * 1024
+	ageColumns     = 64
+	ageTagSize     = 16
+)
+
+var ErrAgeNoIdentity = errors.New("no identity matched the recipients of the file")
+
+var ageBase64 = base64.RawStdEncoding
+
+// AgeIdentity derives the X25519 identity of the label from the secret.
+func AgeIdentity(secret []byte, label string) ([]byte, error) {
+	ikm := make([]byte, 0, len(secret)+len(label)+4)
+	ikm = append(ikm, secret...)
+	ikm = append(ikm, "age:"+label...)
+	keys, err := KDF(context.Background(), ikm, 1, curve25519.ScalarSize, nil)
+	if err != nil {
+		return nil, err
+	}
+	return keys[0], nil
+}
+
+// AgeRecipient returns the public key of the identity.
+func AgeRecipient(identity []byte) ([]byte, error) {
+	return curve25519.X25519(identity, curve25519.Basepoint)
+}
+
+// EncodeAgeIdentity encodes the identity as AGE-SECRET-KEY-1...
+func EncodeAgeIdentity(identity []byte) (string, error) {
+	return Bech32Encode(AgeIdentityHRP, identity)
+}
+
+// EncodeAgeRecipient encodes the public key as age1...
+func EncodeAgeRecipient(pub []byte) (string, error) {
+	return Bech32Encode(AgeRecipientHRP, pub)
+}
+
+// DecodeAgeRecipient decodes the age1... recipient.
+func DecodeAgeRecipient(s string) ([]byte, error) {
+	hrp, b, err := Bech32Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if hrp != AgeRecipientHRP {
+		return nil, fmt.Errorf("not an age recipient: %q", s)
+	}
+	if len(b) != curve25519.PointSize {
+		return nil, fmt.Errorf("invalid age recipient: %q", s)
+	}
+	return b, nil
+}
+
+func ageHKDF(secret, salt []byte, info string) ([]byte, error) {
+	k := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, []byte(info)), k); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+func ageAEADSeal(key, plain []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	return aead.Seal(nil, nonce, plain, nil), nil
+}
+
+func ageAEADOpen(key, data []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	return aead.Open(nil, nonce, data, nil)
+}
+
+// ageStanza is a recipient stanza of the header.
+type ageStanza struct {
+	Type string
+	Args []string
+	Body []byte
+}
+
+func (r *ageStanza) marshal(w *bytes.Buffer) {
+	fmt.Fprintf(w, "-> %s\n", strings.Join(append([]string{r.Type}, r.Args...), " "))
+	b := ageBase64.EncodeToString(r.Body)
+	for len(b) >= ageColumns {
+		w.WriteString(b[:ageColumns] + "\n")
+		b = b[ageColumns:]
+	}
+	w.WriteString(b + "\n")
+}
+
+func ageWrap(fileKey, recipient []byte) (*ageStanza, error) {
+	ephemeral, err := RandomBytes(curve25519.ScalarSize)
+	if err != nil {
+		return nil, err
+	}
+	share, err := curve25519.X25519(ephemeral, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := curve25519.X25519(ephemeral, recipient)
+	if err != nil {
+		return nil, err
+	}
+	salt := append(append([]byte{}, share...), recipient...)
+	key, err := ageHKDF(shared, salt, ageX25519Label)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ageAEADSeal(key, fileKey)
+	if err != nil {
+		return nil, err
+	}
+	return &ageStanza{
+		Type: ageX25519Type,
+		Args: []string{ageBase64.EncodeToString(share)},
+		Body: body,
+	}, nil
+}
+
+func ageUnwrap(s *ageStanza, identity []byte) ([]byte, error) {
+	if s.Type != ageX25519Type {
+		return nil, nil
+	}
+	if len(s.Args) != 1 {
+		return nil, errors.New("invalid X25519 recipient stanza")
+	}
+	share, err := ageBase64.DecodeString(s.Args[0])
+	if err != nil || len(share) != curve25519.PointSize {
+		return nil, errors.New("invalid X25519 recipient stanza")
+	}
+	recipient, err := AgeRecipient(identity)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := curve25519.X25519(identity, share)
+	if err != nil {
+		return nil, err
+	}
+	salt := append(append([]byte{}, share...), recipient...)
+	key, err := ageHKDF(shared, salt, ageX25519Label)
+	if err != nil {
+		return nil, err
+	}
+	fileKey, err := ageAEADOpen(key, s.Body)
+	if err != nil {
+		// not for this identity
+		return nil, nil
+	}
+	if len(fileKey) != ageFileKeySize {
+		return nil, errors.New("invalid file key size")
+	}
+	return fileKey, nil
+}
+
+func ageHeaderMAC(fileKey, header []byte) ([]byte, error) {
+	key, err := ageHKDF(fileKey, nil, "header")
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(header)
+	return mac.Sum(nil), nil
+}
+
+// AgeEncrypt encrypts the input to the recipients in the age format.
+func AgeEncrypt(dst io.Writer, src io.Reader, recipients [][]byte) error {
+	if len(recipients) == 0 {
+		return errors.New("no recipients")
+	}
+	fileKey, err := RandomBytes(ageFileKeySize)
+	if err != nil {
+		return err
+	}
+
+	var hdr bytes.Buffer
+	hdr.WriteString(ageIntro + "\n")
+	for _, r := range recipients {
+		s, err := ageWrap(fileKey, r)
+		if err != nil {
+			return err
+		}
+		s.marshal(&hdr)
+	}
+	hdr.WriteString(ageFooter)
+	mac, err := ageHeaderMAC(fileKey, hdr.Bytes())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(&hdr, " %s\n", ageBase64.EncodeToString(mac))
+
+	nonce, err := RandomBytes(ageNonceSize)
+	if err != nil {
+		return err
+	}
+	hdr.Write(nonce)
+	if _, err := dst.Write(hdr.Bytes()); err != nil {
+		return err
+	}
+
+	key, err := ageHKDF(fileKey, nonce, "payload")
+	if err != nil {
+		return err
+	}
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return err
+	}
+
+	in := bufio.NewReaderSize(src, ageChunkSize)
+	buf := make([]byte, ageChunkSize)
+	var counter uint64
+	for {
+		n, err := io.ReadFull(in, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		last := n < ageChunkSize
+		if !last {
+			if _, err := in.Peek(1); err == io.EOF {
+				last = true
+			}
+		}
+		out := aead.Seal(nil, ageChunkNonce(counter, last), buf[:n], nil)
+		if _, err := dst.Write(out); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+		counter++
+	}
+}
+
+func ageChunkNonce(counter uint64, last bool) []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	for i := 10; i >= 3; i-- {
+		nonce[i] = byte(counter)
+		counter >>= 8
+	}
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// parseAgeHeader reads the header and returns the stanzas, the header bytes
+// covered by the MAC, and the MAC.
+func parseAgeHeader(in *bufio.Reader) ([]*ageStanza, []byte, []byte, error) {
+	var hdr bytes.Buffer
+	readLine := func() (string, error) {
+		line, err := in.ReadString('\n')
+		if err != nil {
+			return "", errors.New("invalid age header")
+		}
+		return strings.TrimSuffix(line, "\n"), nil
+	}
+
+	line, err := readLine()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if line != ageIntro {
+		return nil, nil, nil, fmt.Errorf("unsupported age format: %q", line)
+	}
+	hdr.WriteString(line + "\n")
+
+	var stanzas []*ageStanza
+	for {
+		line, err := readLine()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if strings.HasPrefix(line, ageFooter+" ") {
+			hdr.WriteString(ageFooter)
+			mac, err := ageBase64.DecodeString(strings.TrimPrefix(line, ageFooter+" "))
+			if err != nil {
+				return nil, nil, nil, errors.New("invalid age header MAC")
+			}
+			return stanzas, hdr.Bytes(), mac, nil
+		}
+		if !strings.HasPrefix(line, "-> ") {
+			return nil, nil, nil, errors.New("invalid age header")
+		}
+		hdr.WriteString(line + "\n")
+		args := strings.Split(strings.TrimPrefix(line, "-> "), " ")
+		s := &ageStanza{Type: args[0], Args: args[1:]}
+
+		var body strings.Builder
+		for {
+			line, err := readLine()
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			hdr.WriteString(line + "\n")
+			if len(line) > ageColumns {
+				return nil, nil, nil, errors.New("invalid age stanza body")
+			}
+			body.WriteString(line)
+			if len(line) < ageColumns {
+				break
+			}
+		}
+		if s.Body, err = ageBase64.DecodeString(body.String()); err != nil {
+			return nil, nil, nil, errors.New("invalid age stanza body")
+		}
+		stanzas = append(stanzas, s)
+	}
+}
+
+// AgeDecrypt decrypts the age encrypted input with the identity.
+func AgeDecrypt(dst io.Writer, src io.Reader, identity []byte) error {
+	in := bufio.NewReaderSize(src, ageChunkSize+ageTagSize)
+	stanzas, hdr, mac, err := parseAgeHeader(in)
+	if err != nil {
+		return err
+	}
+
+	var fileKey []byte
+	for _, s := range stanzas {
+		if fileKey, err = ageUnwrap(s, identity); err != nil {
+			return err
+		}
+		if fileKey != nil {
+			break
+		}
+	}
+	if fileKey == nil {
+		return ErrAgeNoIdentity
+	}
+
+	expected, err := ageHeaderMAC(fileKey, hdr)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(mac, expected) {
+		return errors.New("age header MAC mismatch")
+	}
+
+	nonce := make([]byte, ageNonceSize)
+	if _, err := io.ReadFull(in, nonce); err != nil {
+		return errors.New("invalid age payload")
+	}
+	key, err := ageHKDF(fileKey, nonce, "payload")
+	if err != nil {
+		return err
+	}
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, ageChunkSize+aead.Overhead())
+	var counter uint64
+	for {
+		n, err := io.ReadFull(in, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		last := n < len(buf)
+		if !last {
+			if _, err := in.Peek(1); err == io.EOF {
+				last = true
+			}
+		}
+		out, err := aead.Open(nil, ageChunkNonce(counter, last), buf[:n], nil)
+		if err != nil {
+			return errors.New("age payload authentication failed")
+		}
+		if len(out) == 0 && counter > 0 {
+			return errors.New("unexpected empty chunk")
+		}
+		if _, err := dst.Write(out); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+		counter++
+	}
+}
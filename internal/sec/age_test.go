@@ -0,0 +1,148 @@
+package sec
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestAgeIdentity(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	id, err := AgeIdentity(secret, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := AgeIdentity(secret, "default")
+	other, _ := AgeIdentity(secret, "work")
+	if !bytes.Equal(id, again) || bytes.Equal(id, other) {
+		t.Fatalf("got: %x %x %x", id, again, other)
+	}
+
+	pub, err := AgeRecipient(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := EncodeAgeRecipient(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(s, "age1") {
+		t.Fatalf("got: %s", s)
+	}
+	got, err := DecodeAgeRecipient(s)
+	if err != nil || !bytes.Equal(got, pub) {
+		t.Fatalf("got: %x %v want: %x", got, err, pub)
+	}
+
+	s, err = EncodeAgeIdentity(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(s, "AGE-SECRET-KEY-1") {
+		t.Fatalf("got: %s", s)
+	}
+	if _, err := DecodeAgeRecipient(s); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestAgeEncryptDecrypt(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	id, _ := AgeIdentity(secret, "default")
+	pub, _ := AgeRecipient(id)
+	other, _ := AgeIdentity(secret, "other")
+	otherPub, _ := AgeRecipient(other)
+	stranger, _ := AgeIdentity(secret, "stranger")
+
+	sizes := []int{0, 1, 100, ageChunkSize - 1, ageChunkSize, ageChunkSize + 1, 2 * ageChunkSize, 3*ageChunkSize + 7}
+	for i, size := range sizes {
+		data := bytes.Repeat([]byte{byte(i)}, size)
+
+		var enc bytes.Buffer
+		if err := AgeEncrypt(&enc, bytes.NewReader(data), [][]byte{pub, otherPub}); err != nil {
+			t.Fatalf("[%v] %v", i, err)
+		}
+		if !bytes.HasPrefix(enc.Bytes(), []byte(ageIntro+"\n-> X25519 ")) {
+			t.Fatalf("[%v] got: %q", i, enc.Bytes()[:64])
+		}
+
+		for _, key := range [][]byte{id, other} {
+			var dec bytes.Buffer
+			if err := AgeDecrypt(&dec, bytes.NewReader(enc.Bytes()), key); err != nil {
+				t.Fatalf("[%v] %v", i, err)
+			}
+			if !bytes.Equal(dec.Bytes(), data) {
+				t.Fatalf("[%v] got: %v bytes want: %v bytes", i, dec.Len(), size)
+			}
+		}
+
+		if err := AgeDecrypt(&bytes.Buffer{}, bytes.NewReader(enc.Bytes()), stranger); err != ErrAgeNoIdentity {
+			t.Fatalf("[%v] got: %v want: %v", i, err, ErrAgeNoIdentity)
+		}
+
+		// truncated
+		if size > 0 {
+			b := enc.Bytes()[:enc.Len()-1]
+			if err := AgeDecrypt(&bytes.Buffer{}, bytes.NewReader(b), id); err == nil {
+				t.Fatalf("[%v] expected error", i)
+			}
+		}
+	}
+}
+
+func TestAgeDecryptTampered(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	id, _ := AgeIdentity(secret, "default")
+	pub, _ := AgeRecipient(id)
+
+	var enc bytes.Buffer
+	if err := AgeEncrypt(&enc, strings.NewReader("hello"), [][]byte{pub}); err != nil {
+		t.Fatal(err)
+	}
+	b := enc.Bytes()
+	for _, i := range []int{0, 30, len(b) - 40, len(b) - 1} {
+		c := append([]byte{}, b...)
+		c[i] ^= 1
+		if err := AgeDecrypt(&bytes.Buffer{}, bytes.NewReader(c), id); err == nil {
+			t.Fatalf("[%v] expected error", i)
+		}
+	}
+}
+
+// testdata/example.age and example_keys.txt of the reference implementation,
+// filippo.io/age v1.1.1. The recipient is that of age-keygen -y.
+const (
+	ageTestIdentity  = "AGE-SECRET-KEY-184JMZMVQH3E6U0PSL869004Y3U2NYV7R30EU99CSEDNPH02YUVFSZW44VU"
+	ageTestRecipient = "age1cy0su9fwf3gf9mw868g5yut09p6nytfmmnktexz2ya5uqg9vl9sss4euqm"
+	ageTestFile      = "YWdlLWVuY3J5cHRpb24ub3JnL3YxCi0+IFgyNTUxOSA4aHJsTStaQkczRGQ0ZkYyK2E1ODN6ZFRJV0RrOC9SNDFrQ1lac3Z3VFc0CnlPNFBZZGxNV0RKK0N4Z1VOUnFZNVowVC9tK2czRkNoNWpJeEdMYkNWWGMKLS0tIEkvaW1ldlp6eTgxMjBKU3ptSm5tbi9LTWszcDVBMTFWODNOazQxbTlOUEUKcMXlNiShUgdT+Sxa0Q7KsnO6TWEXgHcT6DggQXod8soIGCJyyPhchXc0oTEaO3XpjQ6v"
+	ageTestPlain     = "Black lives matter."
+)
+
+func TestAgeKnownAnswer(t *testing.T) {
+	hrp, id, err := Bech32Decode(ageTestIdentity)
+	if err != nil || hrp != strings.ToLower(AgeIdentityHRP) {
+		t.Fatalf("got: %q %v", hrp, err)
+	}
+	if s, _ := EncodeAgeIdentity(id); s != ageTestIdentity {
+		t.Fatalf("got: %s want: %s", s, ageTestIdentity)
+	}
+
+	pub, err := AgeRecipient(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := EncodeAgeRecipient(pub); s != ageTestRecipient {
+		t.Fatalf("got: %s want: %s", s, ageTestRecipient)
+	}
+
+	b, _ := base64.StdEncoding.DecodeString(ageTestFile)
+	var dec bytes.Buffer
+	if err := AgeDecrypt(&dec, bytes.NewReader(b), id); err != nil {
+		t.Fatal(err)
+	}
+	if dec.String() != ageTestPlain {
+		t.Fatalf("got: %q want: %q", dec.String(), ageTestPlain)
+	}
+}
@@ -0,0 +1,139 @@
+package sec
+
+import (
+	"fmt"
+	"strings"
+)
+
+// https://github.com/bitcoin/bips/blob/master/bip-0173.mediawiki
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+var bech32Generator = []uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+func bech32Polymod(values []byte) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= bech32Generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	h := []byte(strings.ToLower(hrp))
+	var ret []byte
+	for _, c := range h {
+		ret = append(ret, c>>5)
+	}
+	ret = append(ret, 0)
+	for _, c := range h {
+		ret = append(ret, c&31)
+	}
+	return ret
+}
+
+func bech32Checksum(hrp string, data []byte) []byte {
+	values := append(bech32HRPExpand(hrp), data...)
+	values = append(values, 0, 0, 0, 0, 0, 0)
+	mod := bech32Polymod(values) ^ 1
+	ret := make([]byte, 6)
+	for i := range ret {
+		ret[i] = byte(mod>>uint(5*(5-i))) & 31
+	}
+	return ret
+}
+
+// convertBits regroups the bits of data from frombits to tobits per element.
+func convertBits(data []byte, frombits, tobits uint, pad bool) ([]byte, error) {
+	var ret []byte
+	acc := uint32(0)
+	bits := uint(0)
+	maxv := byte(1<<tobits - 1)
+	for _, v := range data {
+		if v>>frombits != 0 {
+			return nil, fmt.Errorf("bech32: invalid data range: %v", v)
+		}
+		acc = acc<<frombits | uint32(v)
+		bits += frombits
+		for bits >= tobits {
+			bits -= tobits
+			ret = append(ret, byte(acc>>bits)&maxv)
+		}
+	}
+	if pad {
+		if bits > 0 {
+			ret = append(ret, byte(acc<<(tobits-bits))&maxv)
+		}
+	} else if bits >= frombits {
+		return nil, fmt.Errorf("bech32: illegal zero padding")
+	} else if byte(acc<<(tobits-bits))&maxv != 0 {
+		return nil, fmt.Errorf("bech32: non-zero padding")
+	}
+	return ret, nil
+}
+
+// Bech32Encode encodes the data with the human readable part hrp. The case of
+// hrp is kept for the whole string.
+func Bech32Encode(hrp string, data []byte) (string, error) {
+	values, err := convertBits(data, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	lower := strings.ToLower(hrp)
+	if hrp != lower && hrp != strings.ToUpper(hrp) {
+		return "", fmt.Errorf("bech32: mixed case hrp: %q", hrp)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(lower)
+	sb.WriteByte('1')
+	for _, v := range append(values, bech32Checksum(lower, values)...) {
+		sb.WriteByte(bech32Charset[v])
+	}
+	if hrp != lower {
+		return strings.ToUpper(sb.String()), nil
+	}
+	return sb.String(), nil
+}
+
+// Bech32Decode decodes the string into the human readable part in lower case
+// and the data.
+func Bech32Decode(s string) (string, []byte, error) {
+	lower := strings.ToLower(s)
+	if s != lower && s != strings.ToUpper(s) {
+		return "", nil, fmt.Errorf("bech32: mixed case")
+	}
+	pos := strings.LastIndex(lower, "1")
+	if pos < 1 || pos+7 > len(lower) {
+		return "", nil, fmt.Errorf("bech32: invalid separator position")
+	}
+	hrp := lower[:pos]
+	for _, c := range []byte(hrp) {
+		if c < 33 || c > 126 {
+			return "", nil, fmt.Errorf("bech32: invalid character in hrp: %q", c)
+		}
+	}
+
+	var values []byte
+	for _, c := range []byte(lower[pos+1:]) {
+		i := strings.IndexByte(bech32Charset, c)
+		if i < 0 {
+			return "", nil, fmt.Errorf("bech32: invalid character: %q", c)
+		}
+		values = append(values, byte(i))
+	}
+	if bech32Polymod(append(bech32HRPExpand(hrp), values...)) != 1 {
+		return "", nil, fmt.Errorf("bech32: invalid checksum")
+	}
+
+	data, err := convertBits(values[:len(values)-6], 5, 8, false)
+	if err != nil {
+		return "", nil, err
+	}
+	return hrp, data, nil
+}
@@ -0,0 +1,60 @@
+package sec
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBech32(t *testing.T) {
+	// valid strings of BIP 173
+	valid := []string{
+		"A12UEL5L",
+		"a12uel5l",
+		"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+		"11qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqc8247j",
+		"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w",
+	}
+	for i, s := range valid {
+		if _, _, err := Bech32Decode(s); err != nil {
+			// 5 bit data that does not regroup into bytes
+			if !strings.Contains(err.Error(), "padding") {
+				t.Fatalf("[%v] %v", i, err)
+			}
+		}
+	}
+
+	invalid := []string{
+		"pzry9x0s0muk",
+		"1pzry9x0s0muk",
+		"x1b4n0q5v",
+		"li1dgmt3",
+		"A1G7SGD8",
+		"10a06t8",
+		"1qzzfhee",
+		"a12UEL5L",
+	}
+	for i, s := range invalid {
+		if _, _, err := Bech32Decode(s); err == nil {
+			t.Fatalf("[%v] expected error: %s", i, s)
+		}
+	}
+
+	data := []byte("secure password assistant")
+	for _, hrp := range []string{"age", "AGE-SECRET-KEY-"} {
+		s, err := Bech32Encode(hrp, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(s, hrp+"1") {
+			t.Fatalf("got: %s", s)
+		}
+		h, b, err := Bech32Decode(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h != strings.ToLower(hrp) || !bytes.Equal(b, data) {
+			t.Fatalf("got: %s %x want: %s %x", h, b, hrp, data)
+		}
+	}
+}
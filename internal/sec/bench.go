@@ -0,0 +1,53 @@
+package sec
+
+import (
+	"time"
+)
+
+// AlgoTiming is the average time of a run of a password hashing algorithm.
+type AlgoTiming struct {
+	Name     string
+	Duration time.Duration
+}
+
+// BenchKDFProfile times each password hashing algorithm of SPA with the work
+// factors of the profile, averaged over runs.
+func BenchKDFProfile(p KDFProfile, keyLen, runs int) ([]AlgoTiming, error) {
+	if runs < 1 {
+		runs = 1
+	}
+	pwd, err := RandomBytes(keyLen)
+	if err != nil {
+		return nil, err
+	}
+	salt, err := RandomBytes(keyLen)
+	if err != nil {
+		return nil, err
+	}
+
+	var timings []AlgoTiming
+	for i, pha := range algos {
+		start := time.Now()
+		for j := 0; j < runs; j++ {
+			if _, err := pha(p, pwd, salt, keyLen); err != nil {
+				return nil, err
+			}
+		}
+		timings = append(timings, AlgoTiming{
+			Name:     algoNames[i],
+			Duration: time.Since(start) / time.Duration(runs),
+		})
+	}
+	return timings, nil
+}
+
+// Memory returns the most memory in bytes needed by an algorithm of the
+// profile, which is also needed by an attacker for each guess.
+func (p KDFProfile) Memory() int64 {
+	scrypt := int64(128) * int64(p.ScryptR) * int64(p.ScryptN)
+	argon2 := int64(p.Argon2Memory) * 1024
+	if scrypt > argon2 {
+		return scrypt
+	}
+	return argon2
+}
@@ -0,0 +1,38 @@
+package sec
+
+import (
+	"testing"
+)
+
+func TestBenchKDFProfile(t *testing.T) {
+	p, _ := LookupKDFProfile("fast-ci")
+	timings, err := BenchKDFProfile(p, 64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(timings) != len(algos) {
+		t.Fatalf("got: %v want: %v", len(timings), len(algos))
+	}
+	for i, v := range timings {
+		if v.Name != algoNames[i] || v.Duration <= 0 {
+			t.Fatalf("[%v] got: %+v", i, v)
+		}
+	}
+}
+
+func TestKDFProfileMemory(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected int64
+	}{
+		{LegacyKDFProfile, 64 << 20},
+		{"fast-ci", 1 << 20},
+		{"paranoid", 128 << 20},
+	}
+	for i, tc := range tests {
+		p, _ := LookupKDFProfile(tc.name)
+		if got := p.Memory(); got != tc.expected {
+			t.Fatalf("[%v] got: %v want: %v", i, got, tc.expected)
+		}
+	}
+}
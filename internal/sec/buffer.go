@@ -0,0 +1,59 @@
+package sec
+
+// Buffer holds secret data such as derived keys. Where supported, its memory
+// is locked so that it is never swapped to disk. Destroy zeroes the memory;
+// the bytes must not be used afterwards.
+type Buffer struct {
+	b      []byte
+	locked bool
+}
+
+// NewBuffer returns a zeroed buffer of n bytes.
+func NewBuffer(n int) *Buffer {
+	b, locked := alloc(n)
+	return &Buffer{b: b, locked: locked}
+}
+
+// NewBufferFrom moves b into a new buffer, b is zeroed.
+func NewBufferFrom(b []byte) *Buffer {
+	r := NewBuffer(len(b))
+	copy(r.b, b)
+	Wipe(b)
+	return r
+}
+
+// Bytes returns the content of the buffer.
+func (r *Buffer) Bytes() []byte {
+	if r == nil {
+		return nil
+	}
+	return r.b
+}
+
+// Len returns the size of the buffer.
+func (r *Buffer) Len() int {
+	return len(r.Bytes())
+}
+
+// Locked reports whether the memory of the buffer is locked.
+func (r *Buffer) Locked() bool {
+	return r != nil && r.locked
+}
+
+// Destroy zeroes and releases the buffer. It is safe to call more than once.
+func (r *Buffer) Destroy() {
+	if r == nil || r.b == nil {
+		return
+	}
+	Wipe(r.b)
+	free(r.b, r.locked)
+	r.b = nil
+	r.locked = false
+}
+
+// Wipe zeroes the bytes in place.
+func Wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !darwin && !linux
+// +build !darwin,!linux
+
+package sec
+
+// alloc returns ordinary memory, locking is not supported.
+func alloc(n int) ([]byte, bool) {
+	return make([]byte, n), false
+}
+
+func free(b []byte, locked bool) {}
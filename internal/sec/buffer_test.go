@@ -0,0 +1,40 @@
+package sec
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBuffer(t *testing.T) {
+	tests := []struct {
+		data []byte
+	}{
+		{[]byte{}},
+		{[]byte("secret")},
+		{bytes.Repeat([]byte{0xff}, 5000)},
+	}
+
+	for i, test := range tests {
+		data := append([]byte(nil), test.data...)
+		r := NewBufferFrom(data)
+		if !bytes.Equal(r.Bytes(), test.data) {
+			t.Fatalf("[%v] got: %v want: %v", i, r.Bytes(), test.data)
+		}
+		if !bytes.Equal(data, make([]byte, len(data))) {
+			t.Fatalf("[%v] source not wiped: %v", i, data)
+		}
+
+		r.Destroy()
+		if r.Bytes() != nil || r.Len() != 0 || r.Locked() {
+			t.Fatalf("[%v] got: %v want: destroyed", i, r.Bytes())
+		}
+		// twice
+		r.Destroy()
+	}
+
+	var r *Buffer
+	r.Destroy()
+	if r.Bytes() != nil {
+		t.Fatalf("got: %v want: nil", r.Bytes())
+	}
+}
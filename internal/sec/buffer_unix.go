@@ -0,0 +1,34 @@
+//go:build darwin || linux
+// +build darwin linux
+
+package sec
+
+import (
+	"syscall"
+)
+
+// alloc maps anonymous pages for the buffer, which are not shared with other
+// data of the Go heap, and locks them. Ordinary memory is used if the pages can
+// not be locked, e.g. RLIMIT_MEMLOCK is exceeded.
+func alloc(n int) ([]byte, bool) {
+	if n == 0 {
+		return make([]byte, 0), false
+	}
+	b, err := syscall.Mmap(-1, 0, n, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		return make([]byte, n), false
+	}
+	if err := syscall.Mlock(b); err != nil {
+		syscall.Munmap(b)
+		return make([]byte, n), false
+	}
+	return b, true
+}
+
+func free(b []byte, locked bool) {
+	if !locked {
+		return
+	}
+	syscall.Munlock(b)
+	syscall.Munmap(b)
+}
@@ -0,0 +1,53 @@
+package sec
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// EncodeUniform maps the key onto n characters of the codebook without modulo
+// bias: bytes not below the largest multiple of the codebook size are
+// rejected. The key is extended by rehashing if it runs out of bytes.
+func EncodeUniform(codebook string, key []byte, n int) string {
+	size := len(codebook)
+	limit := 256 - 256%size
+
+	enc := make([]byte, 0, n)
+	b := HMAC(key, []byte("code"))
+	for i, j := 0, 1; len(enc) < n; i++ {
+		if i == len(b) {
+			b = HMAC(key, []byte("code"+strconv.Itoa(j)))
+			i, j = 0, j+1
+		}
+		if int(b[i]) < limit {
+			enc = append(enc, codebook[int(b[i])%size])
+		}
+	}
+	return string(enc)
+}
+
+// CodeGen returns a function that generates codes of the given length from
+// the codebook for the site, one for each PIN.
+func CodeGen(codebook string, x, y []byte, keyLen, iteration int) func(string, string, string, int, int) ([]string, error) {
+	gen := keyGen(x, y, keyLen, iteration)
+
+	return func(domain, user, pepper string, keyCount, length int) ([]string, error) {
+		if len(codebook) == 0 || len(codebook) > 256 {
+			return nil, fmt.Errorf("invalid codebook size: %v", len(codebook))
+		}
+		if length < 1 {
+			return nil, fmt.Errorf("invalid length: %v", length)
+		}
+
+		keys, err := gen(domain, user, pepper, keyCount)
+		if err != nil {
+			return nil, err
+		}
+
+		var codes []string
+		for _, v := range keys {
+			codes = append(codes, EncodeUniform(codebook, v, length))
+		}
+		return codes, nil
+	}
+}
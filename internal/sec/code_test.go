@@ -0,0 +1,55 @@
+package sec
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestEncodeUniform(t *testing.T) {
+	// a byte value of 250 or above would be biased towards 0-5
+	counts := make([]int, len(numeric))
+	for i := 0; i < 1000; i++ {
+		s := EncodeUniform(numeric, []byte(strconv.Itoa(i)), 100)
+		if len(s) != 100 {
+			t.Fatalf("[%v] got: %v", i, len(s))
+		}
+		for _, c := range []byte(s) {
+			counts[c-'0']++
+		}
+	}
+	// expected 10000 each
+	for i, n := range counts {
+		if n < 9500 || n > 10500 {
+			t.Fatalf("[%v] skewed count: %v %v", i, n, counts)
+		}
+	}
+}
+
+func TestEncodeUniformLong(t *testing.T) {
+	// more than a single hmac worth of bytes
+	s := EncodeUniform(numeric, []byte("key"), 1000)
+	if len(s) != 1000 {
+		t.Fatalf("got: %v", len(s))
+	}
+	if s != EncodeUniform(numeric, []byte("key"), 1000) {
+		t.Fatal("not deterministic")
+	}
+}
+
+func TestCodeGen(t *testing.T) {
+	secret, salt := keyTestdata()
+	gen := CodeGen(numeric, secret, salt, 64, 3)
+
+	codes, err := gen("example.com", "user", "", 10, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range codes {
+		if len(c) != 4 {
+			t.Fatalf("[%v] got: %s", i, c)
+		}
+		if _, err := strconv.Atoi(c); err != nil {
+			t.Fatalf("[%v] got: %s", i, c)
+		}
+	}
+}
@@ -1,16 +1,26 @@
 package sec
 
 import (
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"errors"
 )
 
 const (
 	encryptKeyLen = 32
 )
 
+var ErrCiphertextTooShort = errors.New("ciphertext too short")
+
 func Encrypt(secret, data []byte, iteration int) ([]byte, error) {
+	return EncryptAD(secret, data, nil, iteration)
+}
+
+// EncryptAD is Encrypt with additional data, which is authenticated but not
+// encrypted.
+func EncryptAD(secret, data, ad []byte, iteration int) ([]byte, error) {
 	salt := make([]byte, encryptKeyLen)
 	if _, err := rand.Read(salt); err != nil {
 		return nil, err
@@ -19,6 +29,7 @@ func Encrypt(secret, data []byte, iteration int) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	defer Wipe(key)
 
 	block, err := aes.NewCipher(key)
 	if err != nil {
@@ -32,16 +43,26 @@ func Encrypt(secret, data []byte, iteration int) ([]byte, error) {
 	if _, err = rand.Read(nonce); err != nil {
 		return nil, err
 	}
-	ciphertext := gcm.Seal(nonce, nonce, data, nil)
+	ciphertext := gcm.Seal(nonce, nonce, data, ad)
 	return append(ciphertext, salt...), nil
 }
 
 func Decrypt(secret, data []byte, iteration int) ([]byte, error) {
+	return DecryptAD(secret, data, nil, iteration)
+}
+
+// DecryptAD decrypts the data encrypted by EncryptAD with the same additional
+// data.
+func DecryptAD(secret, data, ad []byte, iteration int) ([]byte, error) {
+	if len(data) < encryptKeyLen {
+		return nil, ErrCiphertextTooShort
+	}
 	salt, ciphertext := data[len(data)-encryptKeyLen:], data[:len(data)-encryptKeyLen]
 	key, err := enckey(secret, salt, iteration)
 	if err != nil {
 		return nil, err
 	}
+	defer Wipe(key)
 
 	block, err := aes.NewCipher(key)
 	if err != nil {
@@ -52,15 +73,54 @@ func Decrypt(secret, data []byte, iteration int) ([]byte, error) {
 		return nil, err
 	}
 	nonceSize := gcm.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, ErrCiphertextTooShort
+	}
 	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
-	return gcm.Open(nil, nonce, ciphertext, nil)
+	return gcm.Open(nil, nonce, ciphertext, ad)
 }
 
 func enckey(secret, salt []byte, iteration int) ([]byte, error) {
-	key, err := SPA(secret, salt, encryptKeyLen, iteration)
+	key, err := SPA(context.Background(), secret, salt, encryptKeyLen, iteration, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	return key, nil
 }
+
+// Seal encrypts data with a random key, e.g. a session key, which unlike the
+// secret of Encrypt is not stretched. The additional data is authenticated.
+func Seal(key, data, ad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, data, ad), nil
+}
+
+// Open decrypts the data sealed by Seal.
+func Open(key, data, ad []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonceSize := gcm.NonceSize()
+	if len(data) < nonceSize {
+		return nil, ErrCiphertextTooShort
+	}
+	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
+	return gcm.Open(nil, nonce, ciphertext, ad)
+}
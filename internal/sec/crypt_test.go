@@ -72,3 +72,34 @@ func BenchmarkDecrypt(b *testing.B) {
 		Decrypt(tc.key, tc.cipher, tc.iteration)
 	}
 }
+
+func TestSeal(t *testing.T) {
+	key, _ := RandomBytes(32)
+	data := []byte("confidential")
+
+	sealed, err := Seal(key, data, []byte("name"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := Open(key, sealed, []byte("name"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plain, data) {
+		t.Fatalf("got: %s want: %s", plain, data)
+	}
+
+	other, _ := RandomBytes(32)
+	tests := []struct {
+		key []byte
+		ad  []byte
+	}{
+		{other, []byte("name")},
+		{key, []byte("other")},
+	}
+	for i, tc := range tests {
+		if _, err := Open(tc.key, sealed, tc.ad); err == nil {
+			t.Fatalf("[%v] got: nil want: error", i)
+		}
+	}
+}
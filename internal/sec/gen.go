@@ -1,6 +1,7 @@
 package sec
 
 import (
+	"context"
 	"sort"
 	"strings"
 )
@@ -56,6 +57,25 @@ func MakeCodebook(codeset, mask string) string {
 }
 
 func KeyGen(codebook string, x, y []byte, keyLen, iteration int) func(string, string, string, int) ([]string, error) {
+	gen := keyGen(x, y, keyLen, iteration)
+
+	return func(domain, user, pepper string, keyCount int) ([]string, error) {
+		keys, err := gen(domain, user, pepper, keyCount)
+		if err != nil {
+			return nil, err
+		}
+
+		var enc []string
+		for _, v := range keys {
+			enc = append(enc, encode(codebook, v, 14))
+			Wipe(v)
+		}
+		return enc, nil
+	}
+}
+
+// keyGen returns a function that derives keyCount raw keys for the site.
+func keyGen(x, y []byte, keyLen, iteration int) func(string, string, string, int) ([][]byte, error) {
 	pud := func(domain, user, pepper string) []byte {
 		s := strings.Join([]string{pepper, user, domain}, ":")
 		return []byte(s)
@@ -74,30 +94,23 @@ func KeyGen(codebook string, x, y []byte, keyLen, iteration int) func(string, st
 		return secret, salt
 	}
 
-	encode := func(b []byte, shift int) string {
-		enc := make([]byte, len(b))
-		for i, v := range b {
-			idx := (shift + int(v)) % len(codebook)
-			enc[i] = codebook[idx]
-		}
-		return string(enc)
-	}
-
-	return func(domain, user, pepper string, keyCount int) ([]string, error) {
+	return func(domain, user, pepper string, keyCount int) ([][]byte, error) {
 		z := pud(domain, user, pepper)
 		secret, salt := pick(z)
 
-		keys, err := SPAKDF(HMAC(secret, z), salt, keyCount, keyLen, iteration)
-		if err != nil {
-			return nil, err
-		}
+		key := HMAC(secret, z)
+		defer Wipe(key)
+		return SPAKDF(context.Background(), key, salt, keyCount, keyLen, iteration, nil)
+	}
+}
 
-		var enc []string
-		for _, v := range keys {
-			enc = append(enc, encode(v, 14))
-		}
-		return enc, nil
+func encode(codebook string, b []byte, shift int) string {
+	enc := make([]byte, len(b))
+	for i, v := range b {
+		idx := (shift + int(v)) % len(codebook)
+		enc[i] = codebook[idx]
 	}
+	return string(enc)
 }
 
 // SpaceOut inserts spaces.
@@ -1,11 +1,14 @@
 package sec
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha512"
 	"hash/fnv"
 	"io"
+	"runtime"
 	"strconv"
+	"sync"
 
 	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/hkdf"
@@ -14,8 +17,13 @@ import (
 )
 
 // https://cheatsheetseries.owasp.org/cheatsheets/Password_Storage_Cheat_Sheet.html#work-factors
+// the work factors are set by the current KDFProfile.
 
-type phaFunc func([]byte, []byte, int) ([]byte, error)
+type phaFunc func(KDFProfile, []byte, []byte, int) ([]byte, error)
+
+// Progress is called after each step of a long running hash with the number of
+// steps done out of total.
+type Progress func(done, total int)
 
 // list of password hashing algorithms
 var algos = []phaFunc{
@@ -24,11 +32,30 @@ var algos = []phaFunc{
 	argon2idKey,
 }
 
-func SPA(secret, salt []byte, keyLen, iteration int) ([]byte, error) {
+// names of the algorithms, in the same order.
+var algoNames = []string{
+	"scrypt",
+	"pbkdf2",
+	"argon2id",
+}
+
+// SPA hashes the secret with one of the algorithms picked by the salt for each
+// iteration, in the scheme of the current KDF profile. It stops between
+// iterations if ctx is done.
+func SPA(ctx context.Context, secret, salt []byte, keyLen, iteration int, progress Progress) ([]byte, error) {
 	if iteration < 1 {
 		iteration = 1
 	}
 
+	p := CurrentKDFProfile()
+	if p.LaneCount() > 1 {
+		return spaLanes(ctx, p, secret, salt, keyLen, iteration, progress)
+	}
+	return spaChain(ctx, p, secret, salt, keyLen, iteration, progress)
+}
+
+// spaChain runs the iterations one after another, each on the previous hash.
+func spaChain(ctx context.Context, p KDFProfile, secret, salt []byte, keyLen, iteration int, progress Progress) ([]byte, error) {
 	pha := func(v int64) phaFunc {
 		cnt := len(algos)
 		which := v % int64(cnt)
@@ -42,42 +69,109 @@ func SPA(secret, salt []byte, keyLen, iteration int) ([]byte, error) {
 	}
 
 	// derive salt for each iteration
-	jar, err := KDF(salt, iteration, keyLen)
+	jar, err := KDF(ctx, salt, iteration, keyLen, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	hash := secret
 	for i, v := range sch {
-		hash, err = pha(v)(hash, jar[i], keyLen)
+		if err := ctx.Err(); err != nil {
+			if i > 0 {
+				Wipe(hash)
+			}
+			return nil, err
+		}
+		next, err := pha(v)(p, hash, jar[i], keyLen)
 		if err != nil {
 			return nil, err
 		}
+		// the intermediate hashes are no longer needed
+		if i > 0 {
+			Wipe(hash)
+		}
+		hash = next
+		if progress != nil {
+			progress(i+1, len(sch))
+		}
 	}
 	return hash, nil
 }
 
-func pbkdf2Key(pwd, salt []byte, keyLen int) ([]byte, error) {
-	iteration := 120000
-	h := pbkdf2.Key(pwd, salt, iteration, keyLen, sha512.New)
+// spaLanes runs a chain of iterations for each lane at the same time. Each lane
+// has its own salt, hence its own schedule of algorithms, and the hashes of all
+// lanes are combined in order.
+func spaLanes(ctx context.Context, p KDFProfile, secret, salt []byte, keyLen, iteration int, progress Progress) ([]byte, error) {
+	lanes := p.LaneCount()
+	n := p.LaneIteration(iteration)
+
+	var laneProgress Progress
+	if progress != nil {
+		var mu sync.Mutex
+		var done int
+		laneProgress = func(int, int) {
+			mu.Lock()
+			defer mu.Unlock()
+			done++
+			progress(done, lanes*n)
+		}
+	}
+
+	hashes := make([][]byte, lanes)
+	errs := make([]error, lanes)
+
+	var wg sync.WaitGroup
+	for i := 0; i < lanes; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			hashes[i], errs[i] = spaChain(ctx, p, secret, laneSalt(salt, i), keyLen, n, laneProgress)
+		}(i)
+	}
+	wg.Wait()
+
+	data := make([]byte, 0, lanes*keyLen)
+	for _, h := range hashes {
+		data = append(data, h...)
+		Wipe(h)
+	}
+	defer Wipe(data)
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	keys, err := KDF(ctx, data, 1, keyLen, nil)
+	if err != nil {
+		return nil, err
+	}
+	return keys[0], nil
+}
+
+func laneSalt(salt []byte, lane int) []byte {
+	return HMAC(salt, []byte("spa-lane:"+strconv.Itoa(lane)))
+}
+
+func pbkdf2Key(p KDFProfile, pwd, salt []byte, keyLen int) ([]byte, error) {
+	h := pbkdf2.Key(pwd, salt, p.PBKDF2Iteration, keyLen, sha512.New)
 	return h, nil
 }
 
-func argon2idKey(pwd, salt []byte, keyLen int) ([]byte, error) {
-	time := 1
-	memory := 64 * 1024
-	threads := 4
-	return argon2.IDKey(pwd, salt, uint32(time), uint32(memory), uint8(threads), uint32(keyLen)), nil
+func argon2idKey(p KDFProfile, pwd, salt []byte, keyLen int) ([]byte, error) {
+	return argon2.IDKey(pwd, salt, p.Argon2Time, p.Argon2Memory, p.Argon2Threads, uint32(keyLen)), nil
 }
 
-func scryptKey(pwd, salt []byte, keyLen int) ([]byte, error) {
-	N := 32768
-	r := 8
-	p := 1
-	return scrypt.Key(pwd, salt, N, r, p, keyLen)
+func scryptKey(p KDFProfile, pwd, salt []byte, keyLen int) ([]byte, error) {
+	return scrypt.Key(pwd, salt, p.ScryptN, p.ScryptR, p.ScryptP, keyLen)
 }
 
-func KDF(secret []byte, keyCount, keyLen int) ([][]byte, error) {
+// the minimum number of keys for each worker of KDF
+const kdfChunk = 64
+
+// KDF derives keyCount keys from the secret. The keys are independent of each
+// other and derived in parallel if there are many. It stops if ctx is done.
+func KDF(ctx context.Context, secret []byte, keyCount, keyLen int, progress Progress) ([][]byte, error) {
 	kdf := func(salt, info []byte, count, klen int) ([][]byte, error) {
 		hash := sha512.New
 		hf := hkdf.New(hash, secret, salt, info)
@@ -92,26 +186,84 @@ func KDF(secret []byte, keyCount, keyLen int) ([][]byte, error) {
 		return keys, nil
 	}
 
-	var h [][]byte
-	for i := 0; i < keyCount; i++ {
-		k, err := kdf([]byte(strconv.Itoa(i)), nil, 1, keyLen)
+	workers := runtime.GOMAXPROCS(0)
+	if n := keyCount / kdfChunk; n < workers {
+		workers = n
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	h := make([][]byte, keyCount)
+	errs := make([]error, workers)
+
+	var mu sync.Mutex
+	var done int
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < keyCount; i += workers {
+				if err := ctx.Err(); err != nil {
+					errs[w] = err
+					return
+				}
+				k, err := kdf([]byte(strconv.Itoa(i)), nil, 1, keyLen)
+				if err != nil {
+					errs[w] = err
+					return
+				}
+				h[i] = k[0]
+				if progress != nil {
+					mu.Lock()
+					done++
+					progress(done, keyCount)
+					mu.Unlock()
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
 		if err != nil {
+			for _, k := range h {
+				Wipe(k)
+			}
 			return nil, err
 		}
-		h = append(h, k[0])
 	}
 	return h, nil
 }
 
-func SPAKDF(raw, salt []byte, keyCount, keyLen, iteration int) ([][]byte, error) {
-	data, err := SPA(raw, salt, keyLen, iteration)
+// SPAKDF derives keyCount keys from the SPA hash of raw. The progress is
+// reported for each iteration of SPA, the much cheaper KDF counts as one more.
+func SPAKDF(ctx context.Context, raw, salt []byte, keyCount, keyLen, iteration int, progress Progress) ([][]byte, error) {
+	if iteration < 1 {
+		iteration = 1
+	}
+	var spaProgress Progress
+	if progress != nil {
+		spaProgress = func(done, total int) {
+			progress(done, total+1)
+		}
+	}
+	data, err := SPA(ctx, raw, salt, keyLen, iteration, spaProgress)
 	if err != nil {
 		return nil, err
 	}
-	ba, err := KDF(data, keyCount, keyLen)
+	if len(data) > 0 && len(raw) > 0 && &data[0] != &raw[0] {
+		defer Wipe(data)
+	}
+	ba, err := KDF(ctx, data, keyCount, keyLen, nil)
 	if err != nil {
 		return nil, err
 	}
+	if progress != nil {
+		progress(iteration+1, iteration+1)
+	}
 	return ba, nil
 }
 
@@ -1,6 +1,8 @@
 package sec
 
 import (
+	"context"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -17,7 +19,7 @@ func TestKDF(t *testing.T) {
 	}
 
 	for i, tc := range testdata {
-		k, err := KDF([]byte(tc.secret), 255, 64)
+		k, err := KDF(context.Background(), []byte(tc.secret), 255, 64, nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -50,7 +52,7 @@ func TestSPA(t *testing.T) {
 	}
 
 	for i, v := range testdata {
-		k, err := SPA([]byte(secret), info(salt, v.domain, v.user), 64, v.n)
+		k, err := SPA(context.Background(), []byte(secret), info(salt, v.domain, v.user), 64, v.n, nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -91,11 +93,149 @@ func hashTestdata() ([]byte, []byte, []byte) {
 	return []byte(secret), []byte(salt), []byte(pepper)
 }
 
+func TestSPAKDFProgress(t *testing.T) {
+	fast, _ := LookupKDFProfile(FastCIKDFProfile)
+	legacy := CurrentKDFProfile()
+	SetKDFProfile(fast)
+	defer SetKDFProfile(legacy)
+
+	secret, salt := []byte("secret"), []byte("salt")
+	var steps [][2]int
+	progress := func(done, total int) {
+		steps = append(steps, [2]int{done, total})
+	}
+	a, err := SPAKDF(context.Background(), secret, salt, 4, 64, 3, progress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 3 iterations of SPA and the KDF
+	if len(steps) != 4 {
+		t.Fatalf("got: %v want: %v", len(steps), 4)
+	}
+	for i, v := range steps {
+		if v != [2]int{i + 1, 4} {
+			t.Fatalf("[%v] got: %v want: %v", i, v, [2]int{i + 1, 4})
+		}
+	}
+
+	// progress does not change the keys
+	b, err := SPAKDF(context.Background(), secret, salt, 4, 64, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range a {
+		if Base64(a[i]) != Base64(b[i]) {
+			t.Fatalf("[%v] got: %x want: %x", i, a[i], b[i])
+		}
+	}
+}
+
+func TestSPAKDFCancel(t *testing.T) {
+	fast, _ := LookupKDFProfile(FastCIKDFProfile)
+	legacy := CurrentKDFProfile()
+	SetKDFProfile(fast)
+	defer SetKDFProfile(legacy)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var done int
+	progress := func(n, total int) {
+		done = n
+		if n == 2 {
+			cancel()
+		}
+	}
+	_, err := SPAKDF(ctx, []byte("secret"), []byte("salt"), 4, 64, 8, progress)
+	if err != context.Canceled {
+		t.Fatalf("got: %v want: %v", err, context.Canceled)
+	}
+	if done != 2 {
+		t.Fatalf("got: %v want: %v", done, 2)
+	}
+}
+
+func TestKDFParallel(t *testing.T) {
+	secret := []byte("secret")
+
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+	want, err := KDF(context.Background(), secret, 1024, 64, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runtime.GOMAXPROCS(4)
+	var done int
+	got, err := KDF(context.Background(), secret, 1024, 64, func(n, total int) {
+		done = n
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done != 1024 {
+		t.Fatalf("got: %v want: %v", done, 1024)
+	}
+	for i := range want {
+		if Base64(got[i]) != Base64(want[i]) {
+			t.Fatalf("[%v] got: %x want: %x", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSPALanes(t *testing.T) {
+	fast, _ := LookupKDFProfile(FastCIKDFProfile)
+	legacy := CurrentKDFProfile()
+	defer SetKDFProfile(legacy)
+
+	secret, salt := []byte("secret"), []byte("salt")
+
+	SetKDFProfile(fast)
+	seq, err := SPA(context.Background(), secret, salt, 64, 5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lanes := fast
+	lanes.Scheme = SPALanes
+	lanes.Lanes = 3
+	SetKDFProfile(lanes)
+
+	var steps []int
+	a, err := SPA(context.Background(), secret, salt, 64, 5, func(n, total int) {
+		if total != 9 {
+			t.Errorf("got: %v want: %v", total, 9)
+		}
+		steps = append(steps, n)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 3 lanes of 3 iterations, one for each algorithm
+	if len(steps) != 9 || steps[8] != 9 {
+		t.Fatalf("got: %v want: %v steps", steps, 9)
+	}
+
+	b, err := SPA(context.Background(), secret, salt, 64, 5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Base64(a) != Base64(b) {
+		t.Fatalf("got: %x want: %x", b, a)
+	}
+	if Base64(a) == Base64(seq) {
+		t.Fatalf("got: %x want: different from sequential", a)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := SPA(ctx, secret, salt, 64, 5, nil); err != context.Canceled {
+		t.Fatalf("got: %v want: %v", err, context.Canceled)
+	}
+}
+
 func BenchmarkKDF(b *testing.B) {
 	secret, _, _ := hashTestdata()
 
 	for i := 0; i < b.N; i++ {
-		KDF(secret, 1, 64)
+		KDF(context.Background(), secret, 1, 64, nil)
 	}
 }
 
@@ -103,7 +243,7 @@ func BenchmarkPbkdf2Key(b *testing.B) {
 	secret, salt, _ := hashTestdata()
 
 	for i := 0; i < b.N; i++ {
-		pbkdf2Key(secret, salt, 64)
+		pbkdf2Key(kdfProfiles[LegacyKDFProfile], secret, salt, 64)
 	}
 }
 
@@ -111,7 +251,7 @@ func BenchmarkAargon2idKey(b *testing.B) {
 	secret, salt, _ := hashTestdata()
 
 	for i := 0; i < b.N; i++ {
-		argon2idKey(secret, salt, 64)
+		argon2idKey(kdfProfiles[LegacyKDFProfile], secret, salt, 64)
 	}
 }
 
@@ -119,7 +259,7 @@ func BenchmarkScryptKey(b *testing.B) {
 	secret, salt, _ := hashTestdata()
 
 	for i := 0; i < b.N; i++ {
-		scryptKey(secret, salt, 64)
+		scryptKey(kdfProfiles[LegacyKDFProfile], secret, salt, 64)
 	}
 }
 
@@ -127,7 +267,7 @@ func BenchmarkSPA(b *testing.B) {
 	secret, salt, _ := hashTestdata()
 
 	for i := 0; i < b.N; i++ {
-		SPA(secret, salt, 64, 1)
+		SPA(context.Background(), secret, salt, 64, 1, nil)
 	}
 }
 
@@ -151,7 +291,7 @@ func benchmarkSPAKDF(iteration int, b *testing.B) {
 	secret, salt, _ := hashTestdata()
 	raw := []byte(secret)
 	for i := 0; i < b.N; i++ {
-		SPAKDF(raw, salt, 1024, 64, iteration)
+		SPAKDF(context.Background(), raw, salt, 1024, 64, iteration, nil)
 	}
 }
 
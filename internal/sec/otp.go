@@ -0,0 +1,56 @@
+package sec
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"strings"
+	"time"
+)
+
+// HOTP returns the RFC 4226 one-time password of the counter.
+func HOTP(key []byte, counter uint64, digits int, algorithm string) (string, error) {
+	var h func() hash.Hash
+	switch strings.ToUpper(algorithm) {
+	case "", "SHA1":
+		h = sha1.New
+	case "SHA256":
+		h = sha256.New
+	case "SHA512":
+		h = sha512.New
+	default:
+		return "", fmt.Errorf("unsupported algorithm: %q", algorithm)
+	}
+	if digits < 6 || digits > 10 {
+		return "", fmt.Errorf("invalid digits: %v", digits)
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(h, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	code := uint64(binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff)
+
+	mod := uint64(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, code%mod), nil
+}
+
+// TOTP returns the RFC 6238 time-based one-time password at time t.
+func TOTP(key []byte, t time.Time, period, digits int, algorithm string) (string, error) {
+	if period < 1 {
+		return "", fmt.Errorf("invalid period: %v", period)
+	}
+	counter := uint64(t.Unix()) / uint64(period)
+	return HOTP(key, counter, digits, algorithm)
+}
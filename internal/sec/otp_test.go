@@ -0,0 +1,61 @@
+package sec
+
+import (
+	"testing"
+	"time"
+)
+
+// https://datatracker.ietf.org/doc/html/rfc6238#appendix-B
+func TestTOTP(t *testing.T) {
+	keys := map[string][]byte{
+		"SHA1":   []byte("12345678901234567890"),
+		"SHA256": []byte("12345678901234567890123456789012"),
+		"SHA512": []byte("1234567890123456789012345678901234567890123456789012345678901234"),
+	}
+	tests := []struct {
+		time      int64
+		algorithm string
+		expected  string
+	}{
+		{59, "SHA1", "94287082"},
+		{59, "SHA256", "46119246"},
+		{59, "SHA512", "90693936"},
+		{1111111109, "SHA1", "07081804"},
+		{1111111109, "SHA256", "68084774"},
+		{1111111109, "SHA512", "25091201"},
+		{1111111111, "SHA1", "14050471"},
+		{1234567890, "SHA256", "91819424"},
+		{2000000000, "SHA512", "38618901"},
+		{20000000000, "SHA1", "65353130"},
+		{20000000000, "SHA256", "77737706"},
+		{20000000000, "SHA512", "47863826"},
+	}
+	for i, tc := range tests {
+		got, err := TOTP(keys[tc.algorithm], time.Unix(tc.time, 0), 30, 8, tc.algorithm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.expected {
+			t.Fatalf("[%v] got: %s want: %s", i, got, tc.expected)
+		}
+	}
+}
+
+// https://datatracker.ietf.org/doc/html/rfc4226#appendix-D
+func TestHOTP(t *testing.T) {
+	key := []byte("12345678901234567890")
+	expected := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for i, v := range expected {
+		got, err := HOTP(key, uint64(i), 6, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != v {
+			t.Fatalf("[%v] got: %s want: %s", i, got, v)
+		}
+	}
+
+	if _, err := HOTP(key, 0, 6, "MD5"); err == nil {
+		t.Fatal("expected error")
+	}
+}
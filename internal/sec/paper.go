@@ -0,0 +1,87 @@
+package sec
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PaperWordsPerLine is the number of data words on each line.
+const PaperWordsPerLine = 8
+
+// EncodePaper encodes data as numbered lines of words for writing down on
+// paper. Each byte is mapped to a word in Wordlist and each line ends with a
+// checksum word computed over the line number and data.
+//
+// 001 able acid acorn actor agent alarm album alley - zebra
+func EncodePaper(data []byte) []string {
+	var lines []string
+	for i, n := 0, 1; i < len(data); i, n = i+PaperWordsPerLine, n+1 {
+		end := i + PaperWordsPerLine
+		if end > len(data) {
+			end = len(data)
+		}
+		chunk := data[i:end]
+
+		words := make([]string, len(chunk))
+		for j, b := range chunk {
+			words[j] = Wordlist[b]
+		}
+		check := Wordlist[paperChecksum(n, chunk)]
+		lines = append(lines, fmt.Sprintf("%03d %s - %s", n, strings.Join(words, " "), check))
+	}
+	return lines
+}
+
+// DecodePaper decodes the lines produced by EncodePaper. Lines must be in
+// order; blank lines are ignored.
+func DecodePaper(lines []string) ([]byte, error) {
+	var data []byte
+	n := 0
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		n++
+
+		// number, words..., "-", checksum
+		if len(fields) < 4 || fields[len(fields)-2] != "-" {
+			return nil, fmt.Errorf("line %03d: malformed: %q", n, line)
+		}
+		num, err := strconv.Atoi(fields[0])
+		if err != nil || num != n {
+			return nil, fmt.Errorf("line %03d: unexpected line number: %q", n, fields[0])
+		}
+
+		words := fields[1 : len(fields)-2]
+		if len(words) > PaperWordsPerLine {
+			return nil, fmt.Errorf("line %03d: too many words: %v", n, len(words))
+		}
+		chunk := make([]byte, len(words))
+		for i, w := range words {
+			b, ok := WordIndex(w)
+			if !ok {
+				return nil, fmt.Errorf("line %03d: unknown word: %q", n, w)
+			}
+			chunk[i] = b
+		}
+		check, ok := WordIndex(fields[len(fields)-1])
+		if !ok || check != paperChecksum(n, chunk) {
+			return nil, fmt.Errorf("line %03d: checksum mismatch, please check the words", n)
+		}
+		data = append(data, chunk...)
+	}
+	return data, nil
+}
+
+func paperChecksum(n int, chunk []byte) byte {
+	h := sha256.New()
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(n))
+	h.Write(b[:])
+	h.Write(chunk)
+	return h.Sum(nil)[0]
+}
@@ -0,0 +1,82 @@
+package sec
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWordlist(t *testing.T) {
+	seen := make(map[string]bool)
+	for i, w := range Wordlist {
+		p := w
+		if len(p) > 4 {
+			p = p[0:4]
+		}
+		if seen[p] {
+			t.Fatalf("[%v] duplicate prefix: %s", i, w)
+		}
+		seen[p] = true
+		if i > 0 && Wordlist[i-1] >= w {
+			t.Fatalf("[%v] not sorted: %s", i, w)
+		}
+	}
+}
+
+func TestWordIndex(t *testing.T) {
+	tests := []struct {
+		word     string
+		expected int
+	}{
+		{"able", 0},
+		{"zipper", 255},
+		{"ZIPPER", 255},
+		{"zipp", 255},
+		{"zippr", 255},
+		{"zip", -1},
+		{"", -1},
+		{"unknown", -1},
+	}
+	for i, tc := range tests {
+		b, ok := WordIndex(tc.word)
+		if (tc.expected < 0 && ok) || (tc.expected >= 0 && (!ok || int(b) != tc.expected)) {
+			t.Fatalf("[%v] got: %v %v want: %v", i, b, ok, tc.expected)
+		}
+	}
+}
+
+func TestPaper(t *testing.T) {
+	for _, size := range []int{0, 1, 7, 8, 9, 96, 1000} {
+		data, _ := RandomBytes(size)
+		lines := EncodePaper(data)
+		if len(lines) != (size+PaperWordsPerLine-1)/PaperWordsPerLine {
+			t.Fatalf("[%v] lines: %v", size, len(lines))
+		}
+		got, err := DecodePaper(lines)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Fatalf("[%v] got: %v want: %v", size, got, data)
+		}
+	}
+}
+
+func TestPaperChecksum(t *testing.T) {
+	data := []byte("0123456789abcdef")
+	lines := EncodePaper(data)
+	t.Log(lines)
+
+	// swap two words
+	f := strings.Fields(lines[1])
+	f[1], f[2] = f[2], f[1]
+	tampered := []string{lines[0], strings.Join(f, " ")}
+	if _, err := DecodePaper(tampered); err == nil || !strings.HasPrefix(err.Error(), "line 002") {
+		t.Fatalf("got: %v", err)
+	}
+
+	// out of order
+	if _, err := DecodePaper([]string{lines[1], lines[0]}); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -1,6 +1,7 @@
 package sec
 
 import (
+	"context"
 	"math"
 	"math/big"
 )
@@ -72,7 +73,7 @@ func factorial(n int64) *big.Int {
 func schedule(n int64, data []byte) ([]int64, error) {
 	kdf := func(size int) ([]byte, error) {
 		c := size/64 + 1
-		ba, err := KDF(data, c, 64)
+		ba, err := KDF(context.Background(), data, c, 64, nil)
 		if err != nil {
 			return nil, err
 		}
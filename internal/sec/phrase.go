@@ -0,0 +1,39 @@
+package sec
+
+import (
+	"crypto/sha512"
+	"fmt"
+	"strings"
+)
+
+// PhraseGen returns a function that generates passphrases of the given number
+// of words from Wordlist for the site, one for each PIN.
+//
+// Each byte of the derived key picks a word; with 256 words the mapping is
+// free of bias. The key is rehashed with a distinct label so that passphrases
+// do not share key material with the passwords of the same site.
+func PhraseGen(x, y []byte, keyLen, iteration int) func(string, string, string, int, int, string) ([]string, error) {
+	gen := keyGen(x, y, keyLen, iteration)
+
+	return func(domain, user, pepper string, keyCount, words int, sep string) ([]string, error) {
+		if words < 1 || words > sha512.Size {
+			return nil, fmt.Errorf("invalid number of words: %v", words)
+		}
+
+		keys, err := gen(domain, user, pepper, keyCount)
+		if err != nil {
+			return nil, err
+		}
+
+		var phrases []string
+		for _, v := range keys {
+			b := HMAC(v, []byte("phrase"))
+			w := make([]string, words)
+			for i := 0; i < words; i++ {
+				w[i] = Wordlist[b[i]]
+			}
+			phrases = append(phrases, strings.Join(w, sep))
+		}
+		return phrases, nil
+	}
+}
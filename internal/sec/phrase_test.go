@@ -0,0 +1,42 @@
+package sec
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPhraseGen(t *testing.T) {
+	secret, salt := keyTestdata()
+	gen := PhraseGen(secret, salt, 64, 3)
+
+	phrases, err := gen("example.com", "user", "", 3, 6, "-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range phrases {
+		words := strings.Split(p, "-")
+		if len(words) != 6 {
+			t.Fatalf("[%v] got: %s", i, p)
+		}
+		for _, w := range words {
+			if _, ok := WordIndex(w); !ok {
+				t.Fatalf("[%v] unknown word: %s", i, w)
+			}
+		}
+	}
+
+	// deterministic
+	again, err := gen("example.com", "user", "", 3, 6, " ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range phrases {
+		if strings.Replace(again[i], " ", "-", -1) != phrases[i] {
+			t.Fatalf("[%v] got: %s want: %s", i, again[i], phrases[i])
+		}
+	}
+
+	if _, err := gen("example.com", "user", "", 1, 0, "-"); err == nil {
+		t.Fatal("expected error")
+	}
+}
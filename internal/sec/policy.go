@@ -0,0 +1,194 @@
+package sec
+
+import (
+	"crypto/sha512"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maximum number of derivations tried for a compliant password
+const maxPolicyAttempt = 10000
+
+// Policy describes the rules a generated password must comply with.
+// Zero values impose no restriction.
+type Policy struct {
+	MinUpper  int
+	MinLower  int
+	MinDigit  int
+	MinSymbol int
+
+	// maximum number of identical consecutive characters
+	MaxRepeat int
+
+	// allowed symbols, all symbols are allowed if empty
+	Symbols string
+}
+
+func isUpper(c byte) bool {
+	return c >= 'A' && c <= 'Z'
+}
+
+func isLower(c byte) bool {
+	return c >= 'a' && c <= 'z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// Restrict removes the symbols not allowed by the policy from the codeset.
+func (r *Policy) Restrict(codeset string) string {
+	if r.Symbols == "" {
+		return codeset
+	}
+	b := make([]byte, 0, len(codeset))
+	for _, c := range []byte(codeset) {
+		if isUpper(c) || isLower(c) || isDigit(c) || strings.IndexByte(r.Symbols, c) >= 0 {
+			b = append(b, c)
+		}
+	}
+	return string(b)
+}
+
+// Validate checks that a password of the length can be generated from the
+// codebook in compliance with the policy.
+func (r *Policy) Validate(codebook string, length int) error {
+	for _, v := range []int{r.MinUpper, r.MinLower, r.MinDigit, r.MinSymbol, r.MaxRepeat} {
+		if v < 0 {
+			return fmt.Errorf("invalid policy: negative value %v", v)
+		}
+	}
+	if n := r.MinUpper + r.MinLower + r.MinDigit + r.MinSymbol; n > length {
+		return fmt.Errorf("invalid policy: %v required characters exceed length %v", n, length)
+	}
+	for _, c := range []byte(r.Symbols) {
+		if c <= ' ' || c > '~' || isUpper(c) || isLower(c) || isDigit(c) {
+			return fmt.Errorf("invalid policy: %q is not a symbol", c)
+		}
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, c := range []byte(codebook) {
+		switch {
+		case isUpper(c):
+			upper = true
+		case isLower(c):
+			lower = true
+		case isDigit(c):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	required := []struct {
+		min   int
+		avail bool
+		class string
+	}{
+		{r.MinUpper, upper, "uppercase letter"},
+		{r.MinLower, lower, "lowercase letter"},
+		{r.MinDigit, digit, "digit"},
+		{r.MinSymbol, symbol, "symbol"},
+	}
+	for _, v := range required {
+		if v.min > 0 && !v.avail {
+			return fmt.Errorf("invalid policy: %s is required but excluded", v.class)
+		}
+	}
+	return nil
+}
+
+// Check reports whether the password complies with the policy.
+func (r *Policy) Check(s string) bool {
+	var upper, lower, digit, symbol int
+	run := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case isUpper(c):
+			upper++
+		case isLower(c):
+			lower++
+		case isDigit(c):
+			digit++
+		default:
+			if r.Symbols != "" && strings.IndexByte(r.Symbols, c) < 0 {
+				return false
+			}
+			symbol++
+		}
+
+		if i > 0 && c == s[i-1] {
+			run++
+		} else {
+			run = 1
+		}
+		if r.MaxRepeat > 0 && run > r.MaxRepeat {
+			return false
+		}
+	}
+	return upper >= r.MinUpper && lower >= r.MinLower && digit >= r.MinDigit && symbol >= r.MinSymbol
+}
+
+// PolicyKeyGen is KeyGen for passwords of the given length that comply with
+// the policy.
+//
+// The first candidate for each PIN is the same as KeyGen's; if it does not
+// comply, the key is rehashed with an attempt counter until the password does.
+// The result is deterministic.
+func PolicyKeyGen(p Policy, codebook string, x, y []byte, keyLen, iteration int) func(string, string, string, int, int) ([]string, error) {
+	return policyKeyGen(p, codebook, func(b []byte, length int) string {
+		return encode(codebook, b, 14)[0:length]
+	}, x, y, keyLen, iteration)
+}
+
+// UniformPolicyKeyGen is PolicyKeyGen with the characters mapped by
+// EncodeUniform, for short codebooks such as digits where the modulo bias of
+// KeyGen is significant. The first candidate is the same as CodeGen's.
+func UniformPolicyKeyGen(p Policy, codebook string, x, y []byte, keyLen, iteration int) func(string, string, string, int, int) ([]string, error) {
+	return policyKeyGen(p, codebook, func(b []byte, length int) string {
+		return EncodeUniform(codebook, b, length)
+	}, x, y, keyLen, iteration)
+}
+
+func policyKeyGen(p Policy, codebook string, enc func([]byte, int) string, x, y []byte, keyLen, iteration int) func(string, string, string, int, int) ([]string, error) {
+	gen := keyGen(x, y, keyLen, iteration)
+
+	comply := func(key []byte, length int) (string, error) {
+		b := key
+		for i := 1; i <= maxPolicyAttempt; i++ {
+			s := enc(b, length)
+			if p.Check(s) {
+				return s, nil
+			}
+			b = HMAC(key, []byte(strconv.Itoa(i)))
+		}
+		return "", fmt.Errorf("failed to generate a password complying with the policy, please relax the policy")
+	}
+
+	return func(domain, user, pepper string, keyCount, length int) ([]string, error) {
+		// rehashed keys are as long as the hmac
+		if length > keyLen || length > sha512.Size {
+			return nil, fmt.Errorf("invalid length: %v", length)
+		}
+		if err := p.Validate(codebook, length); err != nil {
+			return nil, err
+		}
+
+		keys, err := gen(domain, user, pepper, keyCount)
+		if err != nil {
+			return nil, err
+		}
+
+		var enc []string
+		for _, v := range keys {
+			s, err := comply(v, length)
+			if err != nil {
+				return nil, err
+			}
+			enc = append(enc, s)
+		}
+		return enc, nil
+	}
+}
@@ -0,0 +1,126 @@
+package sec
+
+import (
+	"testing"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	tests := []struct {
+		policy   Policy
+		input    string
+		expected bool
+	}{
+		{Policy{}, "", true},
+		{Policy{}, "aaaa", true},
+		{Policy{MinUpper: 1}, "abc1!", false},
+		{Policy{MinUpper: 1}, "aBc1!", true},
+		{Policy{MinLower: 2}, "aBC1!", false},
+		{Policy{MinDigit: 2}, "aBc1!2", true},
+		{Policy{MinSymbol: 2}, "aBc1!", false},
+		{Policy{MinSymbol: 2}, "aB#c1!", true},
+		{Policy{MaxRepeat: 2}, "abbbc", false},
+		{Policy{MaxRepeat: 2}, "abbcbb", true},
+		{Policy{MaxRepeat: 1}, "abab", true},
+		{Policy{Symbols: "!@"}, "ab!@", true},
+		{Policy{Symbols: "!@"}, "ab!#", false},
+		{Policy{MinUpper: 1, MinLower: 1, MinDigit: 1, MinSymbol: 1, MaxRepeat: 1, Symbols: "-_"}, "Ab1-", true},
+	}
+	for i, tc := range tests {
+		if got := tc.policy.Check(tc.input); got != tc.expected {
+			t.Fatalf("[%v] got: %v want: %v", i, got, tc.expected)
+		}
+	}
+}
+
+func TestPolicyRestrict(t *testing.T) {
+	tests := []struct {
+		symbols  string
+		codeset  string
+		expected string
+	}{
+		{"", AlphaNumericSymbol, AlphaNumericSymbol},
+		{"!#", AlphaNumericSymbol, AlphaNumeric + "!#"},
+		{"!#", numeric, numeric},
+		{"!#", "ab!@#", "ab!#"},
+	}
+	for i, tc := range tests {
+		p := Policy{Symbols: tc.symbols}
+		if got := p.Restrict(tc.codeset); got != tc.expected {
+			t.Fatalf("[%v] got: %s want: %s", i, got, tc.expected)
+		}
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	tests := []struct {
+		policy   Policy
+		codebook string
+		length   int
+		ok       bool
+	}{
+		{Policy{}, AlphaNumericSymbol, 8, true},
+		{Policy{MinUpper: 4, MinLower: 4, MinDigit: 1}, AlphaNumericSymbol, 8, false},
+		{Policy{MinSymbol: 1}, AlphaNumeric, 8, false},
+		{Policy{MinDigit: 1}, MakeCodebook(AlphaNumericSymbol, numeric), 8, false},
+		{Policy{MinUpper: -1}, AlphaNumericSymbol, 8, false},
+		{Policy{Symbols: "a!"}, AlphaNumericSymbol, 8, false},
+		{Policy{Symbols: "!#"}, AlphaNumericSymbol, 8, true},
+	}
+	for i, tc := range tests {
+		if err := tc.policy.Validate(tc.codebook, tc.length); (err == nil) != tc.ok {
+			t.Fatalf("[%v] got: %v want ok: %v", i, err, tc.ok)
+		}
+	}
+}
+
+func TestPolicyKeyGen(t *testing.T) {
+	secret, salt := keyTestdata()
+
+	p := Policy{MinUpper: 2, MinLower: 2, MinDigit: 2, MinSymbol: 2, MaxRepeat: 1, Symbols: "!#$%"}
+	codebook := MakeCodebook(p.Restrict(AlphaNumericSymbol), "")
+	gen := PolicyKeyGen(p, codebook, secret, salt, 64, 3)
+
+	for _, length := range []int{8, 16, 32} {
+		pwds, err := gen("example.com", "user", "", 10, length)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, pwd := range pwds {
+			if len(pwd) != length || !p.Check(pwd) {
+				t.Fatalf("[%v] not compliant: %s", i, pwd)
+			}
+		}
+
+		// deterministic
+		again, err := gen("example.com", "user", "", 10, length)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range pwds {
+			if pwds[i] != again[i] {
+				t.Fatalf("[%v] got: %s want: %s", i, again[i], pwds[i])
+			}
+		}
+	}
+}
+
+func TestPolicyKeyGenCompliant(t *testing.T) {
+	secret, salt := keyTestdata()
+	codebook := MakeCodebook(DefaultCodeset, "")
+
+	// already compliant passwords are the same as KeyGen's
+	gen := KeyGen(codebook, secret, salt, 64, 3)
+	pgen := PolicyKeyGen(Policy{MinUpper: 1}, codebook, secret, salt, 64, 3)
+
+	pwds, err := gen("example.com", "user", "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := pgen("example.com", "user", "", 1, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0] != pwds[0] {
+		t.Fatalf("got: %s want: %s", got[0], pwds[0])
+	}
+}
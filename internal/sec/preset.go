@@ -0,0 +1,122 @@
+package sec
+
+import (
+	"sort"
+)
+
+// Preset is a named set of password rules commonly found on web sites.
+//
+// Presets are referred to by name in the saved site config; the values of an
+// existing preset must never change or passwords will change with them.
+type Preset struct {
+	Name        string
+	Description string
+
+	Codeset string
+	Mask    string
+	Policy  Policy
+
+	// characters are mapped without modulo bias, see UniformPolicyKeyGen
+	Uniform bool
+
+	// default and valid range of length
+	Length    int
+	MinLength int
+	MaxLength int
+}
+
+const bankSymbol = "!#$%&*@^"
+
+var presets = map[string]Preset{
+	"default": {
+		Name:        "default",
+		Description: "letters, digits, and symbols except enclosing and escape characters",
+		Codeset:     AlphaNumericSymbol,
+		Mask:        EncloseEscape,
+		Length:      32,
+		MinLength:   8,
+		MaxLength:   64,
+	},
+	"bank16": {
+		Name:        "bank16",
+		Description: "at most 16 characters with each of upper, lower, digit, and common symbols",
+		Codeset:     AlphaNumeric + bankSymbol,
+		Policy:      Policy{MinUpper: 1, MinLower: 1, MinDigit: 1, MinSymbol: 1, MaxRepeat: 2},
+		Length:      16,
+		MinLength:   8,
+		MaxLength:   16,
+	},
+	"oracle": {
+		Name:        "oracle",
+		Description: "Oracle special characters, at most 30 characters",
+		Codeset:     OracleCodeset,
+		Policy:      Policy{MinUpper: 1, MinLower: 1, MinDigit: 1, MinSymbol: 1},
+		Length:      30,
+		MinLength:   8,
+		MaxLength:   30,
+	},
+	"nosymbol": {
+		Name:        "nosymbol",
+		Description: "letters and digits only",
+		Codeset:     AlphaNumeric,
+		Policy:      Policy{MinUpper: 1, MinLower: 1, MinDigit: 1},
+		Length:      32,
+		MinLength:   8,
+		MaxLength:   64,
+	},
+	"nosymbol16": {
+		Name:        "nosymbol16",
+		Description: "letters and digits only, at most 16 characters",
+		Codeset:     AlphaNumeric,
+		Policy:      Policy{MinUpper: 1, MinLower: 1, MinDigit: 1},
+		Length:      16,
+		MinLength:   8,
+		MaxLength:   16,
+	},
+	"numeric4": {
+		Name:        "numeric4",
+		Description: "4 digit PIN",
+		Codeset:     numeric,
+		Policy:      Policy{MaxRepeat: 2},
+		Uniform:     true,
+		Length:      4,
+		MinLength:   4,
+		MaxLength:   4,
+	},
+	"numeric6": {
+		Name:        "numeric6",
+		Description: "6 digit PIN",
+		Codeset:     numeric,
+		Policy:      Policy{MaxRepeat: 2},
+		Uniform:     true,
+		Length:      6,
+		MinLength:   6,
+		MaxLength:   6,
+	},
+	"numeric8": {
+		Name:        "numeric8",
+		Description: "8 digit PIN",
+		Codeset:     numeric,
+		Policy:      Policy{MaxRepeat: 2},
+		Uniform:     true,
+		Length:      8,
+		MinLength:   8,
+		MaxLength:   8,
+	},
+}
+
+// LookupPreset returns the preset of the name.
+func LookupPreset(name string) (Preset, bool) {
+	p, ok := presets[name]
+	return p, ok
+}
+
+// Presets returns all presets sorted by name.
+func Presets() []Preset {
+	var list []Preset
+	for _, v := range presets {
+		list = append(list, v)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
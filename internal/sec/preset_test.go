@@ -0,0 +1,71 @@
+package sec
+
+import (
+	"testing"
+)
+
+func TestPresets(t *testing.T) {
+	for _, p := range Presets() {
+		if q, ok := LookupPreset(p.Name); !ok || q.Name != p.Name {
+			t.Fatalf("[%s] lookup failed", p.Name)
+		}
+		if p.Length < p.MinLength || p.Length > p.MaxLength || p.MaxLength > 64 {
+			t.Fatalf("[%s] invalid length: %v [%v, %v]", p.Name, p.Length, p.MinLength, p.MaxLength)
+		}
+		codebook := MakeCodebook(p.Policy.Restrict(p.Codeset), p.Mask)
+		for _, n := range []int{p.MinLength, p.MaxLength} {
+			if err := p.Policy.Validate(codebook, n); err != nil {
+				t.Fatalf("[%s] %v", p.Name, err)
+			}
+		}
+	}
+}
+
+func TestPresetKeyGen(t *testing.T) {
+	secret, salt := keyTestdata()
+
+	p, _ := LookupPreset("numeric6")
+	codebook := MakeCodebook(p.Codeset, p.Mask)
+	gen := UniformPolicyKeyGen(p.Policy, codebook, secret, salt, 64, 3)
+	pwds, err := gen("example.com", "user", "", 10, p.Length)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, pwd := range pwds {
+		if len(pwd) != p.Length {
+			t.Fatalf("[%v] got: %s", i, pwd)
+		}
+		for _, c := range []byte(pwd) {
+			if !isDigit(c) {
+				t.Fatalf("[%v] got: %s", i, pwd)
+			}
+		}
+	}
+}
+
+func TestNumericPresetUniform(t *testing.T) {
+	secret, salt := keyTestdata()
+
+	for _, name := range []string{"numeric4", "numeric6", "numeric8"} {
+		p, _ := LookupPreset(name)
+		if !p.Uniform {
+			t.Fatalf("[%s] got: %v want: true", name, p.Uniform)
+		}
+
+		// the first candidate of each key is encoded without modulo bias
+		codebook := MakeCodebook(p.Codeset, p.Mask)
+		pwds, err := UniformPolicyKeyGen(Policy{}, codebook, secret, salt, 64, 3)("example.com", "user", "", 10, p.Length)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys, err := keyGen(secret, salt, 64, 3)("example.com", "user", "", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, key := range keys {
+			if want := EncodeUniform(codebook, key, p.Length); pwds[i] != want {
+				t.Fatalf("[%s:%v] got: %s want: %s", name, i, pwds[i], want)
+			}
+		}
+	}
+}
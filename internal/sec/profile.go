@@ -0,0 +1,178 @@
+package sec
+
+import (
+	"sort"
+	"sync"
+)
+
+// KDFProfile is a named set of work factors of the password hashing
+// algorithms and the number of rounds of SPA for each use.
+type KDFProfile struct {
+	Name string
+
+	ScryptN int
+	ScryptR int
+	ScryptP int
+
+	PBKDF2Iteration int
+
+	Argon2Time    uint32
+	Argon2Memory  uint32 // KiB
+	Argon2Threads uint8
+
+	SaltIteration   int
+	SecretIteration int
+	KeyGenIteration int
+	CryptIteration  int
+
+	// construction of SPA and the number of lanes of SPALanes
+	Scheme int
+	Lanes  int
+}
+
+// SPA schemes, the zero value is the original construction.
+const (
+	// SPASequential runs each iteration on the hash of the previous one.
+	SPASequential = iota
+
+	// SPALanes splits the iterations among independent lanes that run at the
+	// same time and combines the hashes of the lanes. Each lane runs every
+	// algorithm at least once, so the total work, and so the cost of each
+	// guess, is not lower than that of SPASequential.
+	SPALanes
+)
+
+// ParallelKDFProfile is the name of the profile with the work factors of the
+// legacy profile and lanes.
+const ParallelKDFProfile = "v2-parallel"
+
+// LegacyKDFProfile is the name of the profile used before profiles were
+// introduced. Its values must never change.
+const LegacyKDFProfile = "v1-legacy"
+
+// FastCIKDFProfile is the name of the profile with minimal work factors, it
+// is only meant for tests.
+const FastCIKDFProfile = "fast-ci"
+
+var kdfProfiles = map[string]KDFProfile{
+	LegacyKDFProfile: {
+		Name:            LegacyKDFProfile,
+		ScryptN:         32768,
+		ScryptR:         8,
+		ScryptP:         1,
+		PBKDF2Iteration: 120000,
+		Argon2Time:      1,
+		Argon2Memory:    64 * 1024,
+		Argon2Threads:   4,
+		SaltIteration:   1024,
+		SecretIteration: 8,
+		KeyGenIteration: 8,
+		CryptIteration:  6,
+	},
+	// for tests and CI only, not secure
+	FastCIKDFProfile: {
+		Name:            FastCIKDFProfile,
+		ScryptN:         1024,
+		ScryptR:         8,
+		ScryptP:         1,
+		PBKDF2Iteration: 1000,
+		Argon2Time:      1,
+		Argon2Memory:    1024,
+		Argon2Threads:   1,
+		SaltIteration:   4,
+		SecretIteration: 1,
+		KeyGenIteration: 1,
+		CryptIteration:  1,
+	},
+	"paranoid": {
+		Name:            "paranoid",
+		ScryptN:         65536,
+		ScryptR:         8,
+		ScryptP:         1,
+		PBKDF2Iteration: 210000,
+		Argon2Time:      2,
+		Argon2Memory:    128 * 1024,
+		Argon2Threads:   4,
+		SaltIteration:   1024,
+		SecretIteration: 16,
+		KeyGenIteration: 8,
+		CryptIteration:  8,
+	},
+	ParallelKDFProfile: {
+		Name:            ParallelKDFProfile,
+		ScryptN:         32768,
+		ScryptR:         8,
+		ScryptP:         1,
+		PBKDF2Iteration: 120000,
+		Argon2Time:      1,
+		Argon2Memory:    64 * 1024,
+		Argon2Threads:   4,
+		SaltIteration:   1024,
+		SecretIteration: 8,
+		KeyGenIteration: 8,
+		CryptIteration:  6,
+		Scheme:          SPALanes,
+		Lanes:           4,
+	},
+}
+
+var (
+	kdfMu      sync.RWMutex
+	kdfProfile = kdfProfiles[LegacyKDFProfile]
+)
+
+// LookupKDFProfile returns the profile of the name.
+func LookupKDFProfile(name string) (KDFProfile, bool) {
+	p, ok := kdfProfiles[name]
+	return p, ok
+}
+
+// KDFProfileNames returns the names of all profiles, sorted.
+func KDFProfileNames() []string {
+	var names []string
+	for k := range kdfProfiles {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetKDFProfile sets the profile used by SPA.
+func SetKDFProfile(p KDFProfile) {
+	kdfMu.Lock()
+	defer kdfMu.Unlock()
+	kdfProfile = p
+}
+
+// CurrentKDFProfile returns the profile used by SPA.
+func CurrentKDFProfile() KDFProfile {
+	kdfMu.RLock()
+	defer kdfMu.RUnlock()
+	return kdfProfile
+}
+
+// LaneCount returns the number of lanes SPA runs at the same time.
+func (p KDFProfile) LaneCount() int {
+	if p.Scheme != SPALanes || p.Lanes < 1 {
+		return 1
+	}
+	return p.Lanes
+}
+
+// LaneIteration returns the number of iterations of each lane, the total is at
+// least iteration. A lane has at least one iteration for each algorithm, as the
+// schedule of n iterations picks algos[v%len(algos)] for v of 0..n-1.
+func (p KDFProfile) LaneIteration(iteration int) int {
+	if iteration < 1 {
+		iteration = 1
+	}
+	n := p.LaneCount()
+	if n == 1 {
+		return iteration
+	}
+	m := (iteration + n - 1) / n
+	if m < len(algos) {
+		m = len(algos)
+	}
+	return m
+}
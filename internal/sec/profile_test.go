@@ -0,0 +1,102 @@
+package sec
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestKDFProfile(t *testing.T) {
+	legacy, ok := LookupKDFProfile(LegacyKDFProfile)
+	if !ok {
+		t.Fatal("legacy profile not found")
+	}
+	// the values before profiles were introduced
+	want := KDFProfile{LegacyKDFProfile, 32768, 8, 1, 120000, 1, 64 * 1024, 4, 1024, 8, 8, 6, SPASequential, 0}
+	if legacy != want {
+		t.Fatalf("got: %+v want: %+v", legacy, want)
+	}
+	if CurrentKDFProfile() != legacy {
+		t.Fatalf("got: %+v want: %+v", CurrentKDFProfile(), legacy)
+	}
+
+	secret, salt := []byte("secret"), []byte("salt")
+	a, err := SPA(context.Background(), secret, salt, 32, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fast, _ := LookupKDFProfile("fast-ci")
+	SetKDFProfile(fast)
+	defer SetKDFProfile(legacy)
+	b, err := SPA(context.Background(), secret, salt, 32, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(a, b) {
+		t.Fatalf("got: %x want: different output", b)
+	}
+
+	for _, name := range KDFProfileNames() {
+		if p, _ := LookupKDFProfile(name); p.Name != name {
+			t.Fatalf("got: %v want: %v", p.Name, name)
+		}
+	}
+}
+
+func TestLaneIteration(t *testing.T) {
+	tests := []struct {
+		scheme    int
+		lanes     int
+		iteration int
+		count     int
+		expected  int
+	}{
+		{SPASequential, 0, 8, 1, 8},
+		{SPASequential, 4, 8, 1, 8},
+		{SPALanes, 0, 8, 1, 8},
+		{SPASequential, 0, 1, 1, 1},
+		{SPALanes, 4, 8, 4, 3},
+		{SPALanes, 4, 6, 4, 3},
+		{SPALanes, 4, 1, 4, 3},
+		{SPALanes, 4, 16, 4, 4},
+		{SPALanes, 4, 1024, 4, 256},
+	}
+	for i, tc := range tests {
+		p := KDFProfile{Scheme: tc.scheme, Lanes: tc.lanes}
+		if p.LaneCount() != tc.count || p.LaneIteration(tc.iteration) != tc.expected {
+			t.Fatalf("[%v] got: %v %v want: %v %v", i, p.LaneCount(), p.LaneIteration(tc.iteration), tc.count, tc.expected)
+		}
+	}
+}
+
+func TestLaneAlgorithms(t *testing.T) {
+	p, _ := LookupKDFProfile(ParallelKDFProfile)
+	stages := []struct {
+		name      string
+		iteration int
+	}{
+		{"salt", p.SaltIteration},
+		{"secret", p.SecretIteration},
+		{"keygen", p.KeyGenIteration},
+		{"crypt", p.CryptIteration},
+	}
+	for i := 0; i < 8; i++ {
+		salt, _ := RandomBytes(64)
+		for _, st := range stages {
+			for lane := 0; lane < p.LaneCount(); lane++ {
+				sch, err := schedule(int64(p.LaneIteration(st.iteration)), laneSalt(salt, lane))
+				if err != nil {
+					t.Fatal(err)
+				}
+				seen := make(map[int64]bool)
+				for _, v := range sch {
+					seen[v%int64(len(algos))] = true
+				}
+				if len(seen) != len(algos) {
+					t.Fatalf("[%v] %s lane %v got: %v algorithms want: %v", i, st.name, lane, len(seen), len(algos))
+				}
+			}
+		}
+	}
+}
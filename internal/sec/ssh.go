@@ -0,0 +1,141 @@
+package sec
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"encoding/binary"
+	"encoding/pem"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	sshKeyType     = "ssh-ed25519"
+	sshMagic       = "openssh-key-v1\x00"
+	sshCipher      = "aes256-ctr"
+	sshKDF         = "bcrypt"
+	sshKDFRounds   = 16
+	sshKDFSaltSize = 16
+)
+
+// SSHKeyGen returns a function that derives ed25519 keys for the site, one for
+// each PIN. The key is rehashed with a distinct label so that SSH keys do not
+// share key material with the passwords of the same site.
+func SSHKeyGen(x, y []byte, keyLen, iteration int) func(string, string, string, int) ([]ed25519.PrivateKey, error) {
+	gen := keyGen(x, y, keyLen, iteration)
+
+	return func(domain, user, pepper string, keyCount int) ([]ed25519.PrivateKey, error) {
+		keys, err := gen(domain, user, pepper, keyCount)
+		if err != nil {
+			return nil, err
+		}
+
+		var priv []ed25519.PrivateKey
+		for _, v := range keys {
+			seed := HMAC(v, []byte(sshKeyType))
+			priv = append(priv, ed25519.NewKeyFromSeed(seed[:ed25519.SeedSize]))
+		}
+		return priv, nil
+	}
+}
+
+// MarshalOpenSSH encodes the private key in the PEM encoded OpenSSH format.
+// The key is encrypted with aes256-ctr and a bcrypt derived key if a
+// passphrase is provided.
+// https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.key
+func MarshalOpenSSH(key ed25519.PrivateKey, comment string, passphrase []byte) ([]byte, error) {
+	pub := key.Public().(ed25519.PublicKey)
+	pk := struct {
+		KeyType string
+		Pub     []byte
+	}{sshKeyType, pub}
+
+	// the check number is derived from the key so that the unencrypted
+	// output is identical each time.
+	check := binary.BigEndian.Uint32(HMAC(key.Seed(), []byte("check")))
+	sk := struct {
+		Check1  uint32
+		Check2  uint32
+		KeyType string
+		Pub     []byte
+		Priv    []byte
+		Comment string
+		Pad     []byte `ssh:"rest"`
+	}{check, check, sshKeyType, pub, key, comment, nil}
+
+	w := struct {
+		CipherName   string
+		KdfName      string
+		KdfOpts      string
+		NumKeys      uint32
+		PubKey       []byte
+		PrivKeyBlock []byte
+	}{
+		CipherName: "none",
+		KdfName:    "none",
+		NumKeys:    1,
+		PubKey:     ssh.Marshal(pk),
+	}
+
+	blockSize := 8
+	if len(passphrase) > 0 {
+		blockSize = aes.BlockSize
+	}
+	n := len(ssh.Marshal(sk))
+	for i := 1; (n+len(sk.Pad))%blockSize != 0; i++ {
+		sk.Pad = append(sk.Pad, byte(i))
+	}
+	w.PrivKeyBlock = ssh.Marshal(sk)
+
+	if len(passphrase) > 0 {
+		salt, err := RandomBytes(sshKDFSaltSize)
+		if err != nil {
+			return nil, err
+		}
+		opts := struct {
+			Salt   []byte
+			Rounds uint32
+		}{salt, sshKDFRounds}
+
+		k, err := bcryptPBKDF(passphrase, salt, sshKDFRounds, 32+aes.BlockSize)
+		if err != nil {
+			return nil, err
+		}
+		c, err := aes.NewCipher(k[:32])
+		if err != nil {
+			return nil, err
+		}
+		cipher.NewCTR(c, k[32:]).XORKeyStream(w.PrivKeyBlock, w.PrivKeyBlock)
+
+		w.CipherName = sshCipher
+		w.KdfName = sshKDF
+		w.KdfOpts = string(ssh.Marshal(opts))
+	}
+
+	b := append([]byte(sshMagic), ssh.Marshal(w)...)
+	return pem.EncodeToMemory(&pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: b}), nil
+}
+
+// MarshalAuthorizedKey encodes the public key for the authorized_keys file.
+func MarshalAuthorizedKey(key ed25519.PrivateKey, comment string) ([]byte, error) {
+	pk, err := ssh.NewPublicKey(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	b := ssh.MarshalAuthorizedKey(pk)
+	if comment == "" {
+		return b, nil
+	}
+	return append(append(b[:len(b)-1], ' '), append([]byte(comment), '\n')...), nil
+}
+
+// FingerprintSHA256 returns the fingerprint of the public key as shown by
+// ssh-keygen -l.
+func FingerprintSHA256(key ed25519.PrivateKey) (string, error) {
+	pk, err := ssh.NewPublicKey(key.Public())
+	if err != nil {
+		return "", err
+	}
+	return ssh.FingerprintSHA256(pk), nil
+}
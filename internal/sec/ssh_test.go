@@ -0,0 +1,111 @@
+package sec
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSSHKeyGen(t *testing.T) {
+	secret, salt := keyTestdata()
+	gen := SSHKeyGen(secret, salt, 64, 3)
+
+	keys, err := gen("example.com", "user", "", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := gen("example.com", "user", "", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range keys {
+		if !bytes.Equal(keys[i], again[i]) {
+			t.Fatalf("[%v] not deterministic", i)
+		}
+		for j := 0; j < i; j++ {
+			if bytes.Equal(keys[i], keys[j]) {
+				t.Fatalf("[%v] same key as [%v]", i, j)
+			}
+		}
+	}
+
+	other, err := gen("example.com", "user", "pepper", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(keys[0], other[0]) {
+		t.Fatal("pepper does not alter the key")
+	}
+}
+
+func TestMarshalOpenSSH(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{7}, ed25519.SeedSize))
+
+	tests := []struct {
+		comment    string
+		passphrase string
+	}{
+		{"", ""},
+		{"user@example.com", ""},
+		{"user@example.com", "secret"},
+		{"a longer comment to change the padding", "secret"},
+	}
+	for i, tc := range tests {
+		b, err := MarshalOpenSSH(key, tc.comment, []byte(tc.passphrase))
+		if err != nil {
+			t.Fatalf("[%v] %v", i, err)
+		}
+
+		var got interface{}
+		if tc.passphrase == "" {
+			got, err = ssh.ParseRawPrivateKey(b)
+		} else {
+			if _, err := ssh.ParseRawPrivateKey(b); err == nil {
+				t.Fatalf("[%v] key is not encrypted", i)
+			}
+			if _, err := ssh.ParseRawPrivateKeyWithPassphrase(b, []byte("wrong")); err == nil {
+				t.Fatalf("[%v] wrong passphrase accepted", i)
+			}
+			got, err = ssh.ParseRawPrivateKeyWithPassphrase(b, []byte(tc.passphrase))
+		}
+		if err != nil {
+			t.Fatalf("[%v] %v", i, err)
+		}
+		if !bytes.Equal(*got.(*ed25519.PrivateKey), key) {
+			t.Fatalf("[%v] got: %x want: %x", i, got, key)
+		}
+	}
+
+	// unencrypted output is deterministic
+	b1, _ := MarshalOpenSSH(key, "c", nil)
+	b2, _ := MarshalOpenSSH(key, "c", nil)
+	if !bytes.Equal(b1, b2) {
+		t.Fatal("not deterministic")
+	}
+}
+
+func TestMarshalAuthorizedKey(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{7}, ed25519.SeedSize))
+
+	b, err := MarshalAuthorizedKey(key, "user@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, comment, _, _, err := ssh.ParseAuthorizedKey(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comment != "user@example.com" || !strings.HasSuffix(string(b), "\n") {
+		t.Fatalf("got: %q", b)
+	}
+	fp, err := FingerprintSHA256(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fp != ssh.FingerprintSHA256(pk) {
+		t.Fatalf("got: %s want: %s", fp, ssh.FingerprintSHA256(pk))
+	}
+}
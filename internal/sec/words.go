@@ -0,0 +1,69 @@
+package sec
+
+import (
+	"strings"
+)
+
+// Wordlist is a list of 256 common english words, one for each byte value.
+//
+// The words are sorted and their first four letters are unique so that a word
+// can be recognized by its prefix. The list must never change.
+var Wordlist = [256]string{
+	"able", "acid", "acorn", "actor", "agent", "alarm", "album", "alley",
+	"amber", "angle", "apple", "arena", "armor", "arrow", "atlas", "autumn",
+	"bacon", "badge", "baker", "bamboo", "banjo", "barrel", "basket", "beach",
+	"beaver", "bench", "berry", "bison", "blade", "bloom", "board", "bottle",
+	"brain", "brave", "bread", "brick", "bridge", "brush", "bucket", "bunny",
+	"butter", "cabin", "cactus", "camel", "candle", "canoe", "canyon", "carpet",
+	"castle", "cave", "cedar", "chair", "chalk", "cheese", "cherry", "circle",
+	"clock", "cloud", "clover", "coast", "coffee", "comet", "copper", "coral",
+	"cotton", "cradle", "crayon", "crown", "daisy", "dance", "dawn", "delta",
+	"desert", "donkey", "dove", "dragon", "drum", "duck", "dune", "eagle",
+	"earth", "easel", "echo", "elder", "elm", "ember", "empire", "engine",
+	"fabric", "falcon", "fence", "ferry", "finch", "flame", "flask", "fleet",
+	"flower", "flute", "forest", "fossil", "fox", "frost", "fruit", "galaxy",
+	"garden", "garlic", "gecko", "gentle", "ginger", "glove", "goat", "golden",
+	"grape", "gravel", "guitar", "hammer", "harbor", "hazel", "helmet", "heron",
+	"hill", "honey", "hunter", "indigo", "island", "ivory", "jacket", "jaguar",
+	"jelly", "jewel", "jungle", "kayak", "kettle", "king", "kitten", "kiwi",
+	"knight", "koala", "ladder", "lagoon", "lamp", "lemon", "letter", "lily",
+	"lion", "lizard", "locket", "lotus", "lumber", "magnet", "mango", "maple",
+	"marble", "meadow", "melon", "mirror", "mitten", "monkey", "moon", "mosaic",
+	"muffin", "museum", "napkin", "nectar", "needle", "nest", "noble", "noodle",
+	"nutmeg", "oasis", "ocean", "olive", "onion", "opera", "orange", "orbit",
+	"orchid", "otter", "owl", "oyster", "paddle", "palace", "panda", "paper",
+	"parrot", "peach", "pebble", "pencil", "pepper", "piano", "pigeon", "pillow",
+	"pirate", "planet", "plum", "pocket", "pony", "potato", "puzzle", "quail",
+	"quartz", "queen", "quilt", "rabbit", "radar", "radio", "raven", "ribbon",
+	"river", "robot", "rocket", "rose", "ruby", "saddle", "salmon", "saturn",
+	"scarf", "shadow", "silver", "sketch", "spider", "spoon", "stone", "sugar",
+	"summer", "sunset", "swan", "table", "tango", "teapot", "tennis", "tiger",
+	"timber", "toast", "tomato", "topaz", "tulip", "tunnel", "turtle", "twig",
+	"valley", "velvet", "violin", "voyage", "wagon", "walnut", "walrus", "window",
+	"winter", "wizard", "wolf", "yacht", "yogurt", "zebra", "zero", "zipper",
+}
+
+var wordIndex = func() map[string]byte {
+	m := make(map[string]byte)
+	for i, w := range Wordlist {
+		m[w] = byte(i)
+		if len(w) > 4 {
+			m[w[0:4]] = byte(i)
+		}
+	}
+	return m
+}()
+
+// WordIndex returns the byte value of the word or its four letter prefix,
+// case insensitive.
+func WordIndex(word string) (byte, bool) {
+	w := strings.ToLower(strings.TrimSpace(word))
+	if b, ok := wordIndex[w]; ok {
+		return b, true
+	}
+	if len(w) > 4 {
+		b, ok := wordIndex[w[0:4]]
+		return b, ok
+	}
+	return 0, false
+}